@@ -0,0 +1,46 @@
+package version
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// AdmitError is returned by Admit for binaries which can't be checked
+// against the constraint at all.
+type AdmitError struct {
+	File   string
+	Reason string
+}
+
+func (e *AdmitError) Error() string {
+	return e.File + ": " + e.Reason
+}
+
+// Admit checks that the Go binary at file satisfies constraint, see
+// ParseConstraint, e.g. before a container is allowed to start it. It
+// returns a *MismatchError if it doesn't, and an *AdmitError if the binary
+// carries no usable build info or is built from a modified working tree,
+// as its version can't be trusted then. See RunAdmit.
+func Admit(file, constraint string) error {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return err
+	}
+
+	report, err := Inspect(file)
+	if err != nil {
+		return err
+	}
+	if report.Confidence < ConfidenceHigh {
+		return &AdmitError{File: file, Reason: "no intact build info found"}
+	}
+
+	for _, s := range report.Info.Settings {
+		if s.Key == "vcs.modified" && s.Value == "true" {
+			return &AdmitError{File: file, Reason: "built from a modified working tree"}
+		}
+	}
+
+	name := strings.TrimSuffix(filepath.Base(file), ".exe")
+	return Pins{{Tool: name, Constraint: c}}.check(report.Info.Path, report.Info.Main.Version, name)
+}
@@ -0,0 +1,144 @@
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Kinds of anomalies found by Inspect.
+const (
+	// AnomalyDirtyRelease is a release version built from a modified
+	// working tree, i.e. from sources that don't match the tag.
+	AnomalyDirtyRelease = "dirty-release"
+
+	// AnomalyFutureTime is a commit time later than the inspection.
+	AnomalyFutureTime = "future-time"
+
+	// AnomalyPathMismatch is a main package outside of the main module.
+	AnomalyPathMismatch = "path-mismatch"
+
+	// AnomalyRevisionMismatch is a pseudo version naming another commit than
+	// the one recorded by the VCS.
+	AnomalyRevisionMismatch = "revision-mismatch"
+
+	// AnomalyVersionMismatch is a version injected with -ldflags -X that
+	// differs from the module version.
+	AnomalyVersionMismatch = "version-mismatch"
+)
+
+// Anomaly is a suspicious combination in the version information of a
+// binary. Anomalies aren't proof of tampering, but a triage signal for
+// security reviews: legitimate binaries rarely have any.
+type Anomaly struct {
+	Kind    string // AnomalyDirtyRelease, ...
+	Message string
+}
+
+func (a Anomaly) String() string {
+	return a.Kind + ": " + a.Message
+}
+
+// futureTimeSlack is how far a commit time may be in the future before it's
+// an anomaly, to allow for clock skew.
+const futureTimeSlack = time.Hour
+
+// findAnomalies returns the anomalies in info as of now.
+func findAnomalies(info *debug.BuildInfo, now time.Time) []Anomaly {
+	var anomalies []Anomaly
+	add := func(kind, format string, args ...interface{}) {
+		anomalies = append(anomalies, Anomaly{Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+
+	version := info.Main.Version
+	mod := GetAppVersion(version)
+	settings := info.Settings
+	if settings == nil {
+		// a nil slice makes GetVcsInfo read the running binary
+		settings = []debug.BuildSetting{}
+	}
+	vcs := GetVcsInfo(settings)
+	if mod == nil || version == "" {
+		mod = &ModVersion{Type: ErrorVersion}
+	}
+
+	isRelease := mod.Type == Release || mod.Type == PreRelease
+	if _, err := Parse(version); err != nil {
+		isRelease = false
+	}
+	if isRelease && (vcs.IsDirty || strings.HasSuffix(version, "+dirty")) {
+		add(AnomalyDirtyRelease, "release %s was built from a modified working tree", version)
+	}
+
+	for _, t := range []time.Time{mod.Time, vcs.LastCommit} {
+		if !t.IsZero() && t.After(now.Add(futureTimeSlack)) {
+			add(AnomalyFutureTime, "commit time %s is in the future", t.UTC().Format(time.RFC3339))
+			break
+		}
+	}
+
+	if info.Path != "" && info.Main.Path != "" && info.Path != "command-line-arguments" &&
+		info.Path != info.Main.Path && !strings.HasPrefix(info.Path, info.Main.Path+"/") {
+		add(AnomalyPathMismatch, "main package %s is not in module %s", info.Path, info.Main.Path)
+	}
+
+	commit, _, _ := strings.Cut(mod.CommitID, "+")
+	if commit != "" && vcs.Revision != "unknown" && !strings.HasPrefix(vcs.Revision, commit) {
+		add(AnomalyRevisionMismatch, "version %s names commit %s, but it was built from %s",
+			version, commit, vcs.Revision)
+	}
+
+	if _, err := Parse(version); err == nil {
+		for _, injected := range injectedVersions(info.Settings) {
+			if Compare(injected, version) != 0 {
+				add(AnomalyVersionMismatch, "version %s is injected into module version %s",
+					injected, version)
+			}
+		}
+	}
+
+	return anomalies
+}
+
+// injectedVersions returns the semantic versions set by -ldflags -X to
+// variables named like "version", e.g. main.Version=1.2.3, with a leading v.
+func injectedVersions(settings []debug.BuildSetting) []string {
+	var ldflags string
+	for _, s := range settings {
+		if s.Key == "-ldflags" {
+			ldflags = s.Value
+		}
+	}
+
+	var versions []string
+	args := strings.Fields(ldflags)
+	for i, arg := range args {
+		var def string
+		switch {
+		case arg == "-X" && i+1 < len(args):
+			def = args[i+1]
+		case strings.HasPrefix(arg, "-X="):
+			def = arg[len("-X="):]
+		default:
+			continue
+		}
+
+		name, value, ok := strings.Cut(strings.Trim(def, `"'`), "=")
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		if !ok || !strings.EqualFold(name, "version") {
+			continue
+		}
+
+		if !strings.HasPrefix(value, "v") {
+			value = "v" + value
+		}
+		if _, err := Parse(value); err == nil {
+			versions = append(versions, value)
+		}
+	}
+
+	return versions
+}
@@ -0,0 +1,129 @@
+package version
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// AppNameSource tells where the application name is derived from when it's
+// neither set by SetAppName nor by a registered command.
+type AppNameSource int
+
+const (
+	NameFromExecutable AppNameSource = iota // base name of os.Executable(), symlinks resolved
+	NameFromInvocation                      // base name of os.Args[0], symlinks kept
+	NameFromModule                          // base of the main package path
+)
+
+var (
+	namesMu        sync.RWMutex
+	nameOverride   string
+	moduleOverride string
+	nameSource     AppNameSource
+)
+
+// SetAppName overrides the application name, i.e. Brief.AppName.
+//
+// Without an override, the name of a command registered by RegisterCommand
+// is used, then the name derived from the AppNameSource, and finally the
+// base of the main package path.
+func SetAppName(name string) {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+
+	nameOverride = name
+}
+
+// SetAppNameSource sets where the application name is derived from. The
+// default NameFromExecutable reports the name of the installed binary even if
+// it has been renamed, NameFromInvocation suits busybox-style multi-call
+// binaries which behave differently depending on the symlink they are invoked
+// through, and NameFromModule restores the module based naming.
+func SetAppNameSource(src AppNameSource) {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+
+	nameSource = src
+}
+
+// SetDisplayModule overrides the module path shown to users, i.e.
+// Brief.ModulePath, for example with a vanity import path or a homepage.
+func SetDisplayModule(modulePath string) {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+
+	moduleOverride = modulePath
+}
+
+// resolveNames works out the application name, its description and the
+// module path to display for info.
+func resolveNames(info *debug.BuildInfo) (name, description, modulePath string) {
+	namesMu.RLock()
+	name, modulePath = nameOverride, moduleOverride
+	src := nameSource
+	namesMu.RUnlock()
+
+	if modulePath == "" {
+		modulePath = info.Path
+	}
+
+	cmd, ok := lookupCommand(info.Path)
+	if ok {
+		description = cmd.Description
+	}
+
+	switch {
+	case name != "":
+	case ok && cmd.Name != "":
+		name = cmd.Name
+	case src == NameFromExecutable:
+		name = executableName()
+	case src == NameFromInvocation:
+		name = invocationName()
+	}
+
+	if name == "" {
+		// info.Path is a slash-separated import path on every platform, so
+		// path.Base must be used here rather than filepath.Base.
+		name = path.Base(info.Path)
+	}
+
+	return name, description, modulePath
+}
+
+// executableName returns the base name of the running executable, with
+// symlinks resolved and without the .exe suffix, or "" if it's unknown.
+func executableName() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+
+	if real, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = real
+	}
+
+	return trimExe(filepath.Base(exe))
+}
+
+// invocationName returns the name the program is invoked as, i.e. the base
+// name of os.Args[0] without the .exe suffix, or "" if it's unknown.
+func invocationName() string {
+	if len(os.Args) == 0 || os.Args[0] == "" {
+		return ""
+	}
+
+	return trimExe(filepath.Base(os.Args[0]))
+}
+
+// trimExe removes the .exe suffix of Windows executables, in any case.
+func trimExe(name string) string {
+	if ext := filepath.Ext(name); strings.EqualFold(ext, ".exe") {
+		return strings.TrimSuffix(name, ext)
+	}
+	return name
+}
@@ -0,0 +1,131 @@
+package version
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AtomChecker is an UpdateChecker reading the releases feed of a
+// repository, in Atom or RSS format. GitHub, Gitea and Forgejo publish one
+// for every public repository, which needs no API token and isn't subject
+// to API rate limits. Pre-releases are ignored.
+type AtomChecker struct {
+	URL    string       // default https://HOST/OWNER/REPO/releases.atom of the module path
+	Client *http.Client // default RemoteOptions.Client
+}
+
+// Latest implements UpdateChecker.
+func (c *AtomChecker) Latest(ctx context.Context, modulePath string) (*ReleaseInfo, error) {
+	u := c.URL
+	if u == "" {
+		host, repo := splitRepo(modulePath, 2)
+		u = "https://" + host + "/" + repo + "/releases.atom"
+	}
+
+	data, err := fetchCached(ctx, c.Client, u, http.Header{
+		"Accept": {"application/atom+xml, application/rss+xml, application/xml"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	releases, err := parseReleaseFeed(data)
+	if err != nil {
+		return nil, fmt.Errorf("release feed %s: %w", u, err)
+	}
+
+	var best *ReleaseInfo
+	for _, r := range releases {
+		info, err := r.info(modulePath)
+		if err != nil || isPrerelease(info.Version) {
+			continue
+		}
+		if best == nil || Compare(info.Version, best.Version) > 0 {
+			best = info
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no release found in the feed %s", u)
+	}
+
+	return best, nil
+}
+
+// feedDocument is the subset of Atom and RSS 2.0 documents needed to list
+// releases; only the fields of the actual format are set.
+type feedDocument struct {
+	XMLName xml.Name
+
+	// Atom
+	Entries []struct {
+		Title   string    `xml:"title"`
+		Updated time.Time `xml:"updated"`
+		Links   []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+
+	// RSS
+	Items []struct {
+		Title   string `xml:"title"`
+		Link    string `xml:"link"`
+		PubDate string `xml:"pubDate"`
+	} `xml:"channel>item"`
+}
+
+// parseReleaseFeed returns the releases listed in an Atom or RSS feed. The
+// tag of a release is the last element of its link, like
+// https://github.com/OWNER/REPO/releases/tag/v1.2.3, or else its title.
+func parseReleaseFeed(data []byte) ([]forgeRelease, error) {
+	var doc feedDocument
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var releases []forgeRelease
+	switch doc.XMLName.Local {
+	case "feed":
+		for _, e := range doc.Entries {
+			link := ""
+			for _, l := range e.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+				}
+			}
+			releases = append(releases, forgeRelease{
+				TagName:     feedTag(link, e.Title),
+				PublishedAt: e.Updated,
+			})
+		}
+	case "rss":
+		for _, item := range doc.Items {
+			t, _ := time.Parse(time.RFC1123Z, item.PubDate)
+			releases = append(releases, forgeRelease{
+				TagName:     feedTag(item.Link, item.Title),
+				PublishedAt: t,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("not an Atom or RSS feed: <%s>", doc.XMLName.Local)
+	}
+
+	return releases, nil
+}
+
+func feedTag(link, title string) string {
+	if i := strings.LastIndex(link, "/tag/"); i >= 0 {
+		if tag, err := url.PathUnescape(link[i+len("/tag/"):]); err == nil {
+			return tag
+		}
+	}
+
+	return strings.TrimSpace(title)
+}
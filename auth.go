@@ -0,0 +1,177 @@
+package version
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// lookupToken returns the first non-empty value of the environment
+// variables in envs, or else the password of the first of hosts found in
+// the netrc file.
+func lookupToken(envs []string, hosts ...string) string {
+	for _, env := range envs {
+		if token := os.Getenv(env); token != "" {
+			return token
+		}
+	}
+
+	for _, host := range hosts {
+		if token := netrcPassword(host); token != "" {
+			return token
+		}
+	}
+
+	return ""
+}
+
+// netrcPassword returns the password of host in $NETRC or ~/.netrc
+// (~/_netrc on Windows), or "" if there is none.
+func netrcPassword(host string) string {
+	file := os.Getenv("NETRC")
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+		file = filepath.Join(home, name)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+
+	return parseNetrc(string(data), host)
+}
+
+// parseNetrc finds the password for host in the content of a netrc file,
+// falling back to the default entry.
+func parseNetrc(data, host string) string {
+	var machine, password, fallback string
+	inDefault, inMacro := false, false
+
+	lines := strings.Split(data, "\n")
+	for _, line := range lines {
+		if inMacro {
+			// macro definitions end at an empty line
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			switch fields[i] {
+			case "machine":
+				if machine == host && password != "" {
+					return password
+				}
+				machine, password, inDefault = "", "", false
+				if i+1 < len(fields) {
+					machine = fields[i+1]
+					i++
+				}
+			case "default":
+				if machine == host && password != "" {
+					return password
+				}
+				machine, password, inDefault = "", "", true
+			case "password":
+				if i+1 < len(fields) {
+					if inDefault {
+						fallback = fields[i+1]
+					} else {
+						password = fields[i+1]
+					}
+					i++
+				}
+			case "login", "account":
+				i++
+			case "macdef":
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+
+	if machine == host && password != "" {
+		return password
+	}
+
+	return fallback
+}
+
+// caClientKey identifies a client built by clientWithCA.
+type caClientKey struct {
+	base   *http.Client
+	caFile string
+}
+
+// caClients caches the clients built by clientWithCA, so that their
+// transports and connections are reused across checks.
+var caClients sync.Map // caClientKey -> *http.Client
+
+// clientWithCA returns a copy of client trusting the system roots plus the
+// PEM certificates in caFile. A nil client means the client of RemoteOptions,
+// or http.DefaultClient. An empty caFile returns client unchanged. The copy
+// is built once per client and caFile.
+func clientWithCA(client *http.Client, caFile string) (*http.Client, error) {
+	if caFile == "" {
+		return client, nil
+	}
+
+	if client == nil {
+		client = currentRemoteOptions().Client
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	key := caClientKey{client, caFile}
+	if c, ok := caClients.Load(key); ok {
+		return c.(*http.Client), nil
+	}
+
+	base, ok := client.Transport.(*http.Transport)
+	if client.Transport == nil {
+		base, ok = http.DefaultTransport.(*http.Transport)
+	}
+	if !ok {
+		return nil, fmt.Errorf("can't add the CA bundle %s to a %T", caFile, client.Transport)
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+
+	transport := base.Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.RootCAs = pool
+
+	c := *client
+	c.Transport = transport
+
+	cached, _ := caClients.LoadOrStore(key, &c)
+	return cached.(*http.Client), nil
+}
@@ -0,0 +1,152 @@
+package version
+
+import (
+	"regexp"
+	"runtime/debug"
+	"strings"
+)
+
+// BuildFlags describes the build settings beyond version control: those
+// that change how the binary behaves at run time regardless of its source
+// code, and those that tell how it was built.
+type BuildFlags struct {
+	Experiments []string         `json:"experiments,omitempty"` // GOEXPERIMENT
+	GODEBUG     []GODEBUGSetting `json:"godebug,omitempty"`     // compile-time GODEBUG defaults
+
+	// PGOProfile is the profile used for profile-guided optimization, as
+	// given by -pgo and resolved by the go command, or empty without PGO.
+	// The toolchain records only the path, not a hash of the content.
+	PGOProfile string `json:"pgoProfile,omitempty"`
+
+	// The build host is not recorded in the binary, so whether it was
+	// cross-compiled can only be told from traces the toolchain left:
+	// Linker is "internal", "external", the external linker command, or
+	// empty if unknown, and CrossCompileHints lists settings typical of cross builds, such
+	// as a compiler prefixed with a target triple.
+	Target            string   `json:"target,omitempty"` // GOOS/GOARCH
+	Cgo               bool     `json:"cgo"`
+	Linker            string   `json:"linker,omitempty"`
+	CrossCompileHints []string `json:"crossCompileHints,omitempty"`
+}
+
+// GODEBUGSetting is a GODEBUG default compiled into the binary, set by the
+// go version of the main module or by //go:debug directives.
+type GODEBUGSetting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// GetBuildFlags extracts BuildFlags from debug.BuildSetting. If settings is
+// nil, GetBuildFlags reads the build info of the running binary.
+func GetBuildFlags(settings []debug.BuildSetting) *BuildFlags {
+	if settings == nil {
+		info, ok := readBuildInfo()
+		if !ok {
+			return nil
+		}
+		settings = info.Settings
+	}
+
+	var goos, goarch string
+	flags := &BuildFlags{}
+	for _, s := range settings {
+		switch s.Key {
+		case "GOOS":
+			goos = s.Value
+		case "GOARCH":
+			goarch = s.Value
+		case "CGO_ENABLED":
+			flags.Cgo = s.Value == "1"
+		case "-ldflags":
+			flags.Linker = linkerOf(s.Value)
+			flags.addCrossHints("-ldflags", s.Value)
+		case "CC", "CXX", "CGO_CFLAGS", "CGO_CPPFLAGS", "CGO_CXXFLAGS", "CGO_LDFLAGS":
+			flags.addCrossHints(s.Key, s.Value)
+		case "GOEXPERIMENT":
+			flags.Experiments = splitList(s.Value)
+		case "-pgo":
+			flags.PGOProfile = s.Value
+		case "DefaultGODEBUG":
+			for _, kv := range splitList(s.Value) {
+				name, value, _ := strings.Cut(kv, "=")
+				flags.GODEBUG = append(flags.GODEBUG, GODEBUGSetting{Name: name, Value: value})
+			}
+		}
+	}
+
+	if goos != "" && goarch != "" {
+		flags.Target = goos + "/" + goarch
+	}
+	if flags.Linker == "" && flags.Target != "" && !flags.Cgo {
+		// cgo builds pick the linker by platform and packages in use
+		flags.Linker = "internal"
+	}
+
+	return flags
+}
+
+// linkerOf tells the linker from the -ldflags build setting.
+func linkerOf(ldflags string) string {
+	linker := ""
+	fields := strings.Fields(ldflags)
+	for i, f := range fields {
+		f = strings.TrimLeft(f, "-")
+		next := ""
+		if i+1 < len(fields) {
+			next = fields[i+1]
+		}
+
+		switch {
+		case f == "linkmode=external" || f == "linkmode" && next == "external":
+			if linker == "" {
+				linker = "external"
+			}
+		case f == "linkmode=internal" || f == "linkmode" && next == "internal":
+			linker = "internal"
+		case strings.HasPrefix(f, "extld="):
+			linker = strings.TrimPrefix(f, "extld=")
+		case f == "extld" && next != "":
+			linker = next
+		}
+	}
+	return linker
+}
+
+// crossCompilerPattern matches compilers prefixed with a target triple, such
+// as aarch64-linux-gnu-gcc or x86_64-w64-mingw32-clang, and compiler flags
+// selecting a target or a sysroot.
+var crossCompilerPattern = regexp.MustCompile(
+	`\b[a-z0-9_]+(-[a-z0-9_]+){2,3}-(gcc|g\+\+|cc|c\+\+|clang|clang\+\+|ld)\b|` +
+		`--?target[= ]\S+|--sysroot[= ]\S+|\bzig cc\b`)
+
+func (flags *BuildFlags) addCrossHints(key, value string) {
+	for _, m := range crossCompilerPattern.FindAllString(value, -1) {
+		flags.CrossCompileHints = append(flags.CrossCompileHints, key+": "+m)
+	}
+}
+
+// splitList splits a comma-separated list, dropping empty elements.
+func splitList(s string) []string {
+	var list []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// instrumentation returns the sanitizers the binary was built with, among
+// "race", "msan" and "asan".
+func instrumentation(settings []debug.BuildSetting) []string {
+	var list []string
+	for _, s := range settings {
+		switch s.Key {
+		case "-race", "-msan", "-asan":
+			if s.Value == "true" {
+				list = append(list, s.Key[1:])
+			}
+		}
+	}
+	return list
+}
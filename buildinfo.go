@@ -0,0 +1,12 @@
+//go:build !tinygo
+// +build !tinygo
+
+package version
+
+import "runtime/debug"
+
+// readToolchainBuildInfo returns the build information embedded by the Go
+// toolchain.
+func readToolchainBuildInfo() (*debug.BuildInfo, bool) {
+	return debug.ReadBuildInfo()
+}
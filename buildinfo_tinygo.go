@@ -0,0 +1,13 @@
+//go:build tinygo
+// +build tinygo
+
+package version
+
+import "runtime/debug"
+
+// readToolchainBuildInfo reports that there is no build information, as
+// TinyGo doesn't embed it into binaries. loadBuildInfo synthesizes it from
+// the other sources of version information instead.
+func readToolchainBuildInfo() (*debug.BuildInfo, bool) {
+	return nil, false
+}
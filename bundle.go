@@ -0,0 +1,172 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Bundle is a snapshot of remote lookups taken on a connected machine, so
+// that update checks keep working on air-gapped hosts where the binary has
+// no egress:
+//
+//	// on the connected machine
+//	version.ExportBundle(ctx, f, version.BundleOptions{
+//		Modules: []string{"github.com/me/app"},
+//		URLs:    []string{"https://vuln.go.dev/index/modules.json"},
+//	})
+//
+//	// on the air-gapped host
+//	version.ImportBundle("/etc/app/version-bundle.json")
+type Bundle struct {
+	Created   time.Time               `json:"created"`
+	Releases  map[string]*ReleaseInfo `json:"releases"`            // latest release by module path
+	Responses map[string][]byte       `json:"responses,omitempty"` // bodies of GET requests by URL
+}
+
+// BundleOptions selects what ExportBundle puts in a bundle.
+type BundleOptions struct {
+	Checker UpdateChecker // finds the latest releases, default NewProxyClient()
+	Modules []string      // default the main module of the running binary
+
+	// URLs are further documents to snapshot, such as the index and entries
+	// of the Go vulnerability database at https://vuln.go.dev. While the
+	// bundle is imported they are served to every lookup of the same URL.
+	URLs []string
+}
+
+// ExportBundle looks up the latest releases and documents selected by opts
+// and writes them as a JSON bundle to w, for ImportBundle on another host.
+func ExportBundle(ctx context.Context, w io.Writer, opts BundleOptions) error {
+	checker := opts.Checker
+	if checker == nil {
+		checker = NewProxyClient()
+	}
+
+	modules := opts.Modules
+	if len(modules) == 0 {
+		info, ok := readBuildInfo()
+		if !ok {
+			return ErrNoBuildInfo
+		}
+		modules = []string{info.Main.Path}
+	}
+
+	b := &Bundle{
+		Created:   time.Now().UTC(),
+		Releases:  make(map[string]*ReleaseInfo, len(modules)),
+		Responses: make(map[string][]byte, len(opts.URLs)),
+	}
+
+	for _, path := range modules {
+		latest, err := checker.Latest(ctx, path)
+		if err != nil {
+			return fmt.Errorf("latest release of %s: %w", path, err)
+		}
+		b.Releases[path] = latest
+	}
+
+	for _, url := range opts.URLs {
+		data, err := fetch(ctx, nil, url, nil)
+		if err != nil {
+			return err
+		}
+		b.Responses[url] = data
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadBundle reads a bundle written by ExportBundle.
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	var b Bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	return &b, nil
+}
+
+// ImportBundle reads the bundle in file and sets it, see SetBundle.
+func ImportBundle(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	b, err := ReadBundle(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	SetBundle(b)
+
+	return nil
+}
+
+var (
+	bundleMu sync.RWMutex
+	bundle   *Bundle
+)
+
+// SetBundle sets the bundle answering remote lookups. While it's set, update
+// checks of the modules it contains fall back to its releases if the live
+// lookup fails, in offline mode, or without a checker, and lookups of its
+// URLs return its documents without touching the network, even in offline
+// mode. Anything else is looked up as usual. A nil b removes the bundle.
+func SetBundle(b *Bundle) {
+	bundleMu.Lock()
+	defer bundleMu.Unlock()
+
+	bundle = b
+}
+
+// Latest implements UpdateChecker with the releases in the bundle.
+func (b *Bundle) Latest(ctx context.Context, modulePath string) (*ReleaseInfo, error) {
+	r, ok := b.Releases[modulePath]
+	if !ok || r == nil {
+		return nil, fmt.Errorf("module %s is not in the bundle of %s",
+			modulePath, b.Created.Format(time.RFC3339))
+	}
+
+	return copyRelease(r), nil
+}
+
+// bundleRelease returns the latest release of modulePath in the current
+// bundle, if any.
+func bundleRelease(modulePath string) (*ReleaseInfo, bool) {
+	bundleMu.RLock()
+	b := bundle
+	bundleMu.RUnlock()
+
+	if b == nil {
+		return nil, false
+	}
+
+	r, err := b.Latest(context.Background(), modulePath)
+	return r, err == nil
+}
+
+// bundleResponse returns the document for url in the current bundle, if any.
+func bundleResponse(url string) ([]byte, bool) {
+	bundleMu.RLock()
+	defer bundleMu.RUnlock()
+
+	if bundle == nil {
+		return nil, false
+	}
+
+	data, ok := bundle.Responses[url]
+	return data, ok
+}
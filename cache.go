@@ -0,0 +1,164 @@
+package version
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the results of network lookups, such as update checks and
+// module proxy queries, so they aren't repeated on every run. Implementations
+// must be safe for concurrent use; a Redis backed one is a few lines.
+type Cache interface {
+	// Get returns the value stored for key, unless it's missing or expired.
+	Get(key string) ([]byte, bool)
+
+	// Set stores value for key, expiring after ttl.
+	Set(key string, value []byte, ttl time.Duration) error
+
+	// Purge removes all entries.
+	Purge() error
+}
+
+// DefaultCacheTTL is the time lookups are cached unless set by SetCache.
+const DefaultCacheTTL = 6 * time.Hour
+
+var (
+	cacheMu    sync.RWMutex
+	cache      Cache
+	cacheTTL   = DefaultCacheTTL
+	cacheReady bool
+)
+
+// SetCache sets the cache shared by all network lookups and the time their
+// results are kept. A nil c disables caching. By default a FileCache in the
+// user's cache directory is used, if there is one.
+func SetCache(c Cache, ttl time.Duration) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	cache, cacheTTL, cacheReady = c, ttl, true
+}
+
+// PurgeCache removes all entries of the current cache.
+func PurgeCache() error {
+	c, _ := currentCache()
+	if c == nil {
+		return nil
+	}
+	return c.Purge()
+}
+
+// currentCache returns the cache and TTL in use, setting up the default
+// cache on first use.
+func currentCache() (Cache, time.Duration) {
+	cacheMu.RLock()
+	c, ttl, ready := cache, cacheTTL, cacheReady
+	cacheMu.RUnlock()
+
+	if ready {
+		return c, ttl
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	if !cacheReady {
+		if fc, err := NewFileCache(); err == nil {
+			cache = fc
+		}
+		cacheReady = true
+	}
+
+	return cache, cacheTTL
+}
+
+// FileCache is a Cache storing each entry as a JSON file in Dir.
+type FileCache struct {
+	Dir string
+}
+
+// NewFileCache returns a FileCache in the go-version directory under
+// os.UserCacheDir().
+func NewFileCache() (*FileCache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileCache{Dir: filepath.Join(dir, "go-version")}, nil
+}
+
+type cacheEntry struct {
+	Key     string    `json:"key"`
+	Expires time.Time `json:"expires"`
+	Value   []byte    `json:"value"`
+}
+
+func (c *FileCache) file(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// Get implements Cache.
+func (c *FileCache) Get(key string) ([]byte, bool) {
+	file := c.file(key)
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil || e.Key != key {
+		return nil, false
+	}
+
+	if time.Now().After(e.Expires) {
+		os.Remove(file)
+		return nil, false
+	}
+
+	return e.Value, true
+}
+
+// Set implements Cache. The file is replaced atomically, so concurrent
+// processes never see partial entries.
+func (c *FileCache) Set(key string, value []byte, ttl time.Duration) error {
+	data, err := json.Marshal(cacheEntry{
+		Key:     key,
+		Expires: time.Now().Add(ttl),
+		Value:   value,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(c.file(key), data)
+}
+
+// Purge implements Cache.
+func (c *FileCache) Purge() error {
+	entries, err := os.ReadDir(c.Dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".json") || strings.HasPrefix(e.Name(), "tmp-") {
+			if err := os.Remove(filepath.Join(c.Dir, e.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package version
+
+import (
+	"net/url"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// DetectCallingModule returns the module that called into the module of its
+// caller, so that a framework can attribute log or telemetry records to the
+// plugin or extension module using it. Frames of the caller's own module are
+// skipped; if all frames belong to it, its own module is returned. ok is
+// false if there is no build info.
+//
+// See CallerModule to look up a fixed frame instead.
+func DetectCallingModule() (mod *debug.Module, ok bool) {
+	info, ok := readBuildInfo()
+	if !ok {
+		return nil, false
+	}
+
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var own *debug.Module
+	for {
+		frame, more := frames.Next()
+		if m := moduleOfFunc(info, frame.Function); m != nil {
+			switch {
+			case own == nil:
+				own = m
+			case m.Path != own.Path:
+				return m, true
+			}
+		}
+		if !more {
+			break
+		}
+	}
+
+	return own, own != nil
+}
+
+// CallerModule returns the module of the function skip frames up the stack,
+// with the same meaning of skip as runtime.Caller: 0 is the caller of
+// CallerModule. ok is false if the module can't be determined.
+func CallerModule(skip int) (mod *debug.Module, ok bool) {
+	info, ok := readBuildInfo()
+	if !ok {
+		return nil, false
+	}
+
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return nil, false
+	}
+
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return nil, false
+	}
+
+	mod = moduleOfFunc(info, fn.Name())
+
+	return mod, mod != nil
+}
+
+// moduleOfFunc returns a copy of the module in info providing the function
+// with the fully qualified name fn, e.g. "github.com/me/lib/pkg.(*T).Method",
+// or nil if none does, e.g. for functions of the standard library.
+func moduleOfFunc(info *debug.BuildInfo, fn string) *debug.Module {
+	pkg := funcPackage(fn)
+	if pkg == "" {
+		return nil
+	}
+
+	if pkg == "main" || hasPathPrefix(pkg, info.Main.Path) {
+		m := copyModule(&info.Main)
+		return &m
+	}
+
+	// the longest matching path wins, modules may be nested
+	var best *debug.Module
+	for _, dep := range info.Deps {
+		if hasPathPrefix(pkg, dep.Path) && (best == nil || len(dep.Path) > len(best.Path)) {
+			best = dep
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	m := copyModule(best)
+	return &m
+}
+
+// funcPackage returns the import path of the package of the function fn.
+func funcPackage(fn string) string {
+	// type arguments of generic functions may contain slashes and dots
+	if i := strings.IndexByte(fn, '['); i >= 0 {
+		fn = fn[:i]
+	}
+
+	slash := strings.LastIndexByte(fn, '/')
+	dot := strings.IndexByte(fn[slash+1:], '.')
+	if dot < 0 {
+		return ""
+	}
+
+	// the linker escapes dots in the last element of the import path, e.g.
+	// gopkg.in/yaml%2ev3.Marshal, so that they aren't taken for the one
+	// before the function name
+	pkg := fn[:slash+1+dot]
+	if unescaped, err := url.PathUnescape(pkg); err == nil {
+		pkg = unescaped
+	}
+	return pkg
+}
+
+func hasPathPrefix(s, prefix string) bool {
+	return prefix != "" && (s == prefix || strings.HasPrefix(s, prefix+"/"))
+}
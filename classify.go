@@ -0,0 +1,79 @@
+package version
+
+// BuildClass is a coarse classification of a build, for feature flag
+// systems and canary routers which treat release candidates and
+// development builds differently from releases, see Classify. Its string
+// form is stable and safe to store or match on.
+type BuildClass int
+
+const (
+	BuildUnknown     BuildClass = iota // the version can't be determined
+	BuildStable                        // a release from a clean tree
+	BuildCandidate                     // a pre-release, e.g. v1.2.0-rc.1
+	BuildDevelopment                   // anything else: devel, pseudo versions, nightlies, dirty trees
+)
+
+var buildClassNames = [...]string{
+	BuildUnknown:     "unknown",
+	BuildStable:      "stable",
+	BuildCandidate:   "candidate",
+	BuildDevelopment: "development",
+}
+
+func (c BuildClass) String() string {
+	if c >= 0 && int(c) < len(buildClassNames) {
+		return buildClassNames[c]
+	}
+	return buildClassNames[BuildUnknown]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c BuildClass) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, the reverse of
+// MarshalText. Unknown names decode as BuildUnknown.
+func (c *BuildClass) UnmarshalText(text []byte) error {
+	*c = BuildUnknown
+	for i, name := range buildClassNames {
+		if name == string(text) {
+			*c = BuildClass(i)
+		}
+	}
+
+	return nil
+}
+
+// Classify returns the class of the running build.
+func Classify() BuildClass {
+	d, err := GetDetail()
+	if err != nil {
+		return BuildUnknown
+	}
+
+	return d.Class()
+}
+
+// Class returns the class of the build described by d.
+func (d *Detail) Class() BuildClass {
+	switch d.Type {
+	case ErrorVersion:
+		return BuildUnknown
+	case Release, PreRelease:
+	default:
+		return BuildDevelopment
+	}
+
+	v, err := Parse(d.AppVersion)
+	switch {
+	case err != nil:
+		return BuildUnknown
+	case d.IsDirty || v.Build == "dirty":
+		return BuildDevelopment
+	case v.Prerelease != "":
+		return BuildCandidate
+	}
+
+	return BuildStable
+}
@@ -0,0 +1,372 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exit codes of the Run helpers.
+const (
+	ExitTrue  = 0 // the comparison or check holds
+	ExitFalse = 1 // the comparison or check doesn't hold
+	ExitUsage = 2 // bad arguments
+)
+
+// compareOps maps the operators accepted by RunCompare to the results of
+// Version.Compare satisfying them.
+var compareOps = map[string][]int{
+	"lt": {-1}, "<": {-1},
+	"le": {-1, 0}, "<=": {-1, 0},
+	"eq": {0}, "==": {0}, "=": {0},
+	"ne": {-1, 1}, "!=": {-1, 1},
+	"ge": {0, 1}, ">=": {0, 1},
+	"gt": {1}, ">": {1},
+}
+
+// RunCompare implements a `version compare` subcommand, so that the semver
+// logic of this package is scriptable without writing Go:
+//
+//	app version compare v1.2.3 v1.3.0-rc.1
+//	v1.2.3 < v1.3.0-rc.1 (minor)
+//
+// With two versions it prints their ordering and the most significant part
+// in which they differ, see Version.Diff, and returns ExitTrue. With an
+// operator between them (lt, le, eq, ne, ge, gt or <, <=, ==, !=, >=, >) it
+// prints nothing and returns ExitTrue or ExitFalse, e.g. for shell scripts:
+//
+//	if app version compare "$have" ge v1.4.0; then ...
+//
+// The leading "v" of versions is optional. The result is meant to be the
+// exit code of the process: os.Exit(version.RunCompare(args)).
+func RunCompare(args []string) int {
+	return runCompare(args, os.Stdout, os.Stderr)
+}
+
+func runCompare(args []string, stdout, stderr io.Writer) int {
+	var a, op, b string
+	switch len(args) {
+	case 2:
+		a, b = args[0], args[1]
+	case 3:
+		a, op, b = args[0], args[1], args[2]
+	default:
+		fmt.Fprintln(stderr, "usage: compare VERSION [OPERATOR] VERSION")
+		return ExitUsage
+	}
+
+	v, err := parseArg(a)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+	w, err := parseArg(b)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+
+	c := v.Compare(w)
+
+	if op != "" {
+		results, ok := compareOps[op]
+		if !ok {
+			fmt.Fprintf(stderr, "unknown operator %q\n", op)
+			return ExitUsage
+		}
+		for _, r := range results {
+			if r == c {
+				return ExitTrue
+			}
+		}
+		return ExitFalse
+	}
+
+	sign := map[int]string{-1: "<", 0: "=", 1: ">"}[c]
+	line := fmt.Sprintf("%s %s %s", v, sign, w)
+	if diff := v.Diff(w); diff != "" {
+		line += " (" + diff + ")"
+	}
+	fmt.Fprintln(stdout, line)
+
+	return ExitTrue
+}
+
+// parseArg parses a version given on the command line, where the leading
+// "v" may be omitted.
+func parseArg(s string) (*Version, error) {
+	if !strings.HasPrefix(s, "v") {
+		s = "v" + s
+	}
+	return Parse(s)
+}
+
+// RunCheck implements a `version check` subcommand, which checks a version
+// against a constraint, see ParseConstraint:
+//
+//	app version check ">=1.4, <2"          # the running binary
+//	app version check ">=1.4, <2" v1.5.0   # a given version
+//
+// It returns ExitTrue if the constraint is satisfied, and ExitFalse with
+// the reason printed to stderr otherwise, which suits Makefiles and install
+// scripts: os.Exit(version.RunCheck(args)).
+func RunCheck(args []string) int {
+	return runCheck(args, os.Stderr)
+}
+
+func runCheck(args []string, stderr io.Writer) int {
+	switch len(args) {
+	case 1:
+		err := RequireVersion(args[0])
+		if err == nil {
+			return ExitTrue
+		}
+		fmt.Fprintln(stderr, err)
+		if _, ok := err.(*MismatchError); ok {
+			return ExitFalse
+		}
+		return ExitUsage
+	case 2:
+	default:
+		fmt.Fprintln(stderr, "usage: check CONSTRAINT [VERSION]")
+		return ExitUsage
+	}
+
+	c, err := ParseConstraint(args[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+
+	v, err := parseArg(args[1])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+
+	if !c.Check(v) {
+		fmt.Fprintf(stderr, "version %s doesn't satisfy %q\n", v, args[0])
+		return ExitFalse
+	}
+
+	return ExitTrue
+}
+
+// RunVerifyStamp implements a `version verify-stamp` subcommand for release
+// pipelines, which fails the release if the freshly built binary doesn't
+// carry the version of the tag being released, see VerifyStamp:
+//
+//	app version verify-stamp dist/app "$GITHUB_REF_NAME"
+//	app version verify-stamp --run dist/app v1.2.3   # check its --version output
+//
+// It returns ExitTrue if the versions match, and ExitFalse with the reason
+// printed to stderr otherwise: os.Exit(version.RunVerifyStamp(args)).
+func RunVerifyStamp(args []string) int {
+	return runVerifyStamp(args, os.Stderr)
+}
+
+func runVerifyStamp(args []string, stderr io.Writer) int {
+	run := len(args) > 0 && (args[0] == "--run" || args[0] == "-run")
+	if run {
+		args = args[1:]
+	}
+	if len(args) != 2 {
+		fmt.Fprintln(stderr, "usage: verify-stamp [--run] BINARY TAG")
+		return ExitUsage
+	}
+
+	var err error
+	if run {
+		err = VerifyStampOutput(context.Background(), args[0], args[1])
+	} else {
+		err = VerifyStamp(args[0], args[1])
+	}
+
+	if err == nil {
+		return ExitTrue
+	}
+	fmt.Fprintln(stderr, err)
+	if _, ok := err.(*StampError); ok {
+		return ExitFalse
+	}
+	return ExitUsage
+}
+
+// RunCrashLoop implements a `version crash-loop` subcommand for wrapper
+// scripts, which tells whether the running version crashed at least COUNT
+// times within WINDOW, see DetectCrashLoop:
+//
+//	if app version crash-loop 3 10m; then app-rollback; fi
+//
+// It returns ExitTrue with the crash count printed to stdout if so, and
+// ExitFalse otherwise: os.Exit(version.RunCrashLoop(args)).
+func RunCrashLoop(args []string) int {
+	return runCrashLoop(args, os.Stdout, os.Stderr)
+}
+
+func runCrashLoop(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 2 {
+		fmt.Fprintln(stderr, "usage: crash-loop COUNT WINDOW")
+		return ExitUsage
+	}
+
+	count, err := strconv.Atoi(args[0])
+	if err != nil || count < 1 {
+		fmt.Fprintf(stderr, "invalid count %q\n", args[0])
+		return ExitUsage
+	}
+
+	window, err := time.ParseDuration(args[1])
+	if err != nil || window <= 0 {
+		fmt.Fprintf(stderr, "invalid window %q\n", args[1])
+		return ExitUsage
+	}
+
+	loop, err := DetectCrashLoop(window)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+
+	if loop.Crashes < count {
+		return ExitFalse
+	}
+	fmt.Fprintln(stdout, loop)
+	return ExitTrue
+}
+
+// AdmitEnv is the environment variable RunAdmit reads the constraint from.
+const AdmitEnv = "GO_VERSION_CONSTRAINT"
+
+// RunAdmit implements a `version admit` subcommand for init containers and
+// entrypoints, which blocks pods running unapproved builds: it inspects
+// the Go binary of the main container, e.g. on a volume shared with it,
+// and checks it against the constraint given as argument or in the
+// GO_VERSION_CONSTRAINT environment variable, see Admit:
+//
+//	initContainers:
+//	- name: admit
+//	  image: registry.example.com/app:v1.4.2
+//	  command: ["/app", "version", "admit", "/shared/bin/app"]
+//	  env:
+//	  - name: GO_VERSION_CONSTRAINT
+//	    value: ">=1.4, <2"
+//
+// It returns ExitTrue if the binary is admitted, and ExitFalse with the
+// reason printed to stderr otherwise: os.Exit(version.RunAdmit(args)).
+func RunAdmit(args []string) int {
+	return runAdmit(args, os.Stderr)
+}
+
+func runAdmit(args []string, stderr io.Writer) int {
+	constraint := os.Getenv(AdmitEnv)
+	switch len(args) {
+	case 1:
+	case 2:
+		constraint = args[1]
+	default:
+		fmt.Fprintln(stderr, "usage: admit BINARY [CONSTRAINT]")
+		return ExitUsage
+	}
+	if constraint == "" {
+		fmt.Fprintf(stderr, "admit: no constraint given, set %s\n", AdmitEnv)
+		return ExitUsage
+	}
+
+	err := Admit(args[0], constraint)
+	if err == nil {
+		return ExitTrue
+	}
+
+	fmt.Fprintf(stderr, "admission denied: %v\n", err)
+	switch err.(type) {
+	case *MismatchError, *AdmitError:
+		return ExitFalse
+	}
+	return ExitUsage
+}
+
+// RunPrintEnv implements a `--print-env` mode for container entrypoints,
+// which prints the variables of ExportEnv as shell assignments:
+//
+//	eval "$(app --print-env)"        # APP_VERSION, APP_COMMIT, APP_BUILD_DATE
+//	eval "$(app --print-env MYAPP)"  # MYAPP_VERSION, ...
+//
+// It returns ExitTrue, or ExitUsage if the build info can't be read:
+// os.Exit(version.RunPrintEnv(args)).
+func RunPrintEnv(args []string) int {
+	return runPrintEnv(args, os.Stdout, os.Stderr)
+}
+
+func runPrintEnv(args []string, stdout, stderr io.Writer) int {
+	prefix := ""
+	switch len(args) {
+	case 0:
+	case 1:
+		prefix = args[0]
+	default:
+		fmt.Fprintln(stderr, "usage: --print-env [PREFIX]")
+		return ExitUsage
+	}
+
+	env, err := buildEnv(prefix)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+
+	for _, k := range sortedKeys(env) {
+		fmt.Fprintf(stdout, "export %s=%s\n", k, shellQuote(env[k]))
+	}
+
+	return ExitTrue
+}
+
+// RevealTokenEnv is the environment variable RunReveal reads the token
+// from.
+const RevealTokenEnv = "GO_VERSION_REVEAL_TOKEN"
+
+// RunReveal implements a hidden `--reveal-build` flag for support builds
+// of white-label binaries, which prints the genuine build info hidden by
+// the embargo as JSON, see RevealDetail. The token is read from the
+// GO_VERSION_REVEAL_TOKEN environment variable, so that it doesn't show
+// in process listings, or else from the argument.
+//
+// It returns ExitTrue, ExitFalse if the token is refused, or ExitUsage:
+// os.Exit(version.RunReveal(args)).
+func RunReveal(args []string) int {
+	return runReveal(args, os.Stdout, os.Stderr)
+}
+
+func runReveal(args []string, stdout, stderr io.Writer) int {
+	token := os.Getenv(RevealTokenEnv)
+	switch len(args) {
+	case 0:
+	case 1:
+		token = args[0]
+	default:
+		fmt.Fprintln(stderr, "usage: --reveal-build [TOKEN]")
+		return ExitUsage
+	}
+
+	d, err := RevealDetail(token)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		if errors.Is(err, ErrNotAuthorized) {
+			return ExitFalse
+		}
+		return ExitUsage
+	}
+
+	if err := (JSONFormatter{}).Render(*d, stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+
+	return ExitTrue
+}
@@ -0,0 +1,333 @@
+// Command versionres generates platform resource metadata from the version
+// of a Go application, so that what the operating system shows about the
+// binary always matches what --version prints.
+//
+// For Windows (-format json, the default) it emits a versioninfo.json for
+// goversioninfo, which turns it into a .syso file picked up by go build:
+//
+//	//go:generate go run github.com/flw-cn/go-version/cmd/versionres -version v1.2.3 -o versioninfo.json
+//	//go:generate go run github.com/josephspurrier/goversioninfo/cmd/goversioninfo
+//
+// For macOS app bundles (-format plist) it emits the CFBundleShortVersionString
+// and CFBundleVersion entries of Info.plist, or patches them into an existing
+// Info.plist given by -plist:
+//
+//	versionres -format plist -binary MyApp.app/Contents/MacOS/myapp -plist MyApp.app/Contents/Info.plist
+//
+// The version is taken from -version, or read from an already built binary
+// given by -binary.
+package main
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/flw-cn/go-version"
+)
+
+type options struct {
+	format      string
+	plist       string
+	build       string
+	version     string
+	binary      string
+	output      string
+	name        string
+	description string
+	company     string
+	copyright   string
+}
+
+func main() {
+	var opts options
+
+	flag.StringVar(&opts.format, "format", "json", "output format: json (goversioninfo) or plist")
+	flag.StringVar(&opts.plist, "plist", "", "Info.plist to patch, implies -format plist")
+	flag.StringVar(&opts.build, "build", "", "CFBundleVersion (default the numeric version)")
+	flag.StringVar(&opts.version, "version", "", "module version, e.g. v1.2.3")
+	flag.StringVar(&opts.binary, "binary", "", "read the version from this Go binary")
+	flag.StringVar(&opts.output, "o", "", "output file (default stdout)")
+	flag.StringVar(&opts.name, "name", "", "product name (default base of the module path)")
+	flag.StringVar(&opts.description, "description", "", "file description")
+	flag.StringVar(&opts.company, "company", "", "company name")
+	flag.StringVar(&opts.copyright, "copyright", "", "legal copyright")
+	flag.Parse()
+
+	if err := run(&opts); err != nil {
+		fmt.Fprintln(os.Stderr, "versionres:", err)
+		os.Exit(1)
+	}
+}
+
+func run(opts *options) error {
+	revision := ""
+
+	if opts.binary != "" {
+		info, err := buildinfo.ReadFile(opts.binary)
+		if err != nil {
+			return err
+		}
+		if opts.version == "" {
+			opts.version = info.Main.Version
+		}
+		if opts.name == "" {
+			opts.name = path.Base(info.Path)
+		}
+		settings := info.Settings
+		if settings == nil {
+			// a nil slice makes GetVcsInfo read versionres itself
+			settings = []debug.BuildSetting{}
+		}
+		revision = version.GetVcsInfo(settings).Revision
+	}
+
+	if opts.version == "" {
+		return fmt.Errorf("either -version or -binary must be given")
+	}
+
+	verInfo := version.GetAppVersion(opts.version)
+	if verInfo == nil {
+		return fmt.Errorf("invalid version %q", opts.version)
+	}
+	if verInfo.CommitID != "" {
+		revision = verInfo.CommitID
+	}
+
+	if opts.plist != "" {
+		opts.format = "plist"
+		if opts.output == "" {
+			opts.output = opts.plist
+		}
+	}
+
+	var plist []byte
+	if opts.plist != "" {
+		var err error
+		if plist, err = os.ReadFile(opts.plist); err != nil {
+			return err
+		}
+	}
+
+	// rendered completely before the output is touched, as it may be the
+	// input plist
+	var buf bytes.Buffer
+	var err error
+	switch opts.format {
+	case "json":
+		err = writeVersionInfo(&buf, opts, verInfo, revision)
+	case "plist":
+		err = writePlist(&buf, plist, opts, verInfo)
+	default:
+		err = fmt.Errorf("unknown format %q", opts.format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.output == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+
+	return writeFileAtomic(opts.output, buf.Bytes())
+}
+
+// writeFileAtomic writes data to a temporary file next to file and renames
+// it to file, so that file is never left truncated. An existing file keeps
+// its permissions.
+func writeFileAtomic(file string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if fi, err := os.Stat(file); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+// fixedVersion is the numeric version in a VS_FIXEDFILEINFO structure.
+type fixedVersion struct {
+	Major int
+	Minor int
+	Patch int
+	Build int
+}
+
+// numericVersion converts a module version to the numeric form Windows
+// expects. Pseudo versions use the tag they are based on.
+func numericVersion(v string, verInfo *version.ModVersion) fixedVersion {
+	if verInfo.Tag != "" {
+		v = verInfo.Tag
+	}
+
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+
+	var fv fixedVersion
+	parts := strings.Split(v, ".")
+	for i, p := range []*int{&fv.Major, &fv.Minor, &fv.Patch} {
+		if i < len(parts) {
+			*p, _ = strconv.Atoi(parts[i])
+		}
+	}
+
+	return fv
+}
+
+func (fv fixedVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", fv.Major, fv.Minor, fv.Patch)
+}
+
+func writeVersionInfo(w io.Writer, opts *options, verInfo *version.ModVersion, revision string) error {
+	fv := numericVersion(opts.version, verInfo)
+
+	fileFlags := "00"
+	if verInfo.Type != version.Release {
+		fileFlags = "02" // VS_FF_PRERELEASE
+	}
+
+	comments := ""
+	if revision != "" && revision != "unknown" {
+		comments = "revision " + revision
+	}
+
+	filename := opts.name
+	if filename != "" && !strings.HasSuffix(filename, ".exe") {
+		filename += ".exe"
+	}
+
+	// The layout follows versioninfo.json of goversioninfo, including the
+	// trailing space in its "FileFlags " key.
+	vi := map[string]interface{}{
+		"FixedFileInfo": map[string]interface{}{
+			"FileVersion":    fv,
+			"ProductVersion": fv,
+			"FileFlagsMask":  "3f",
+			"FileFlags ":     fileFlags,
+			"FileOS":         "040004",
+			"FileType":       "01",
+			"FileSubType":    "00",
+		},
+		"StringFileInfo": map[string]string{
+			"Comments":         comments,
+			"CompanyName":      opts.company,
+			"FileDescription":  opts.description,
+			"FileVersion":      opts.version,
+			"InternalName":     opts.name,
+			"LegalCopyright":   opts.copyright,
+			"OriginalFilename": filename,
+			"ProductName":      opts.name,
+			"ProductVersion":   opts.version,
+		},
+		"VarFileInfo": map[string]interface{}{
+			"Translation": map[string]string{
+				"LangID":    "0409",
+				"CharsetID": "04B0",
+			},
+		},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(vi)
+}
+
+// writePlist writes the bundle version keys. If plist is not empty, it is
+// the content of an existing Info.plist which is written back with the keys
+// replaced or added.
+func writePlist(w io.Writer, plist []byte, opts *options, verInfo *version.ModVersion) error {
+	short := numericVersion(opts.version, verInfo).String()
+	build := opts.build
+	if build == "" {
+		build = short
+	}
+
+	keys := []struct{ key, value string }{
+		{"CFBundleShortVersionString", short},
+		{"CFBundleVersion", build},
+	}
+
+	if len(plist) == 0 {
+		for _, k := range keys {
+			fmt.Fprintf(w, "<key>%s</key>\n<string>%s</string>\n", escapeXML(k.key), escapeXML(k.value))
+		}
+		return nil
+	}
+
+	for _, k := range keys {
+		var err error
+		if plist, err = setPlistString(plist, k.key, k.value); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write(plist)
+	return err
+}
+
+// setPlistString sets a string entry of the top level dict in an XML plist.
+func setPlistString(plist []byte, key, value string) ([]byte, error) {
+	key, value = escapeXML(key), escapeXML(value)
+
+	re := regexp.MustCompile(`(<key>` + regexp.QuoteMeta(key) + `</key>\s*<string>)[^<]*(</string>)`)
+	if re.Match(plist) {
+		// literally, as "$" in the value would be expanded otherwise
+		return re.ReplaceAllFunc(plist, func(m []byte) []byte {
+			sub := re.FindSubmatch(m)
+			return append(append(append([]byte{}, sub[1]...), value...), sub[2]...)
+		}), nil
+	}
+
+	i := bytes.LastIndex(plist, []byte("</dict>"))
+	if i < 0 {
+		return nil, fmt.Errorf("can't find the top level dict in plist")
+	}
+	i = len(bytes.TrimRight(plist[:i], " \t\r\n"))
+
+	entry := fmt.Sprintf("\n\t<key>%s</key>\n\t<string>%s</string>", key, value)
+
+	var out []byte
+	out = append(out, plist[:i]...)
+	out = append(out, entry...)
+	out = append(out, plist[i:]...)
+	return out, nil
+}
+
+// escapeXML escapes s for XML character data.
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package version
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"os"
+)
+
+// CohortKey returns the cohort of this host for the running version, one
+// of n buckets numbered from 0, so that staged rollouts can be keyed off
+// the binary without an assignment service, e.g. enabling a feature on
+// hosts with a cohort below 10 of 100. See CohortOf.
+func CohortKey(salt string, n int) int {
+	version := "unknown"
+	if d, err := GetDetail(); err == nil {
+		version = d.AppVersion
+	}
+
+	hostname, _ := os.Hostname()
+
+	return CohortOf(salt, version, hostname, n)
+}
+
+// CohortOf returns the cohort of a version on a host, one of n buckets
+// numbered from 0. It's deterministic, so that a router can compute the
+// cohort of any host, and the same on every platform. Each version
+// reshuffles the hosts, so that the same hosts don't always get new
+// versions first; use a different salt for each rollout or feature to
+// reshuffle them too. It returns 0 if n isn't positive.
+func CohortOf(salt, version, hostname string, n int) int {
+	if n <= 0 {
+		return 0
+	}
+
+	h := sha256.New()
+	for _, s := range []string{salt, version, hostname} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	sum := h.Sum(nil)
+
+	return int(binary.BigEndian.Uint64(sum[:8]) % uint64(n))
+}
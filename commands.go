@@ -0,0 +1,45 @@
+package version
+
+import "sync"
+
+// Command describes one of the commands built from a multi-binary module.
+type Command struct {
+	Name        string // display name, used as Brief.AppName
+	Description string // one-line description, available as Brief.Description
+}
+
+var (
+	commandsMu sync.RWMutex
+	commands   = map[string]Command{}
+)
+
+// RegisterCommand maps key to a command, so that each command built from the
+// same module can show its own name and description. key is either the
+// import path of the command's main package, e.g. github.com/you/mod/cmd/foo,
+// or the name the command is invoked as, i.e. the base name of os.Args[0]
+// without the .exe suffix.
+func RegisterCommand(key string, cmd Command) {
+	commandsMu.Lock()
+	defer commandsMu.Unlock()
+
+	commands[key] = cmd
+}
+
+// lookupCommand finds the command registered for the main package path or
+// for the invocation name of the running binary.
+func lookupCommand(pkgPath string) (Command, bool) {
+	commandsMu.RLock()
+	defer commandsMu.RUnlock()
+
+	if cmd, ok := commands[pkgPath]; ok {
+		return cmd, true
+	}
+
+	if name := invocationName(); name != "" {
+		if cmd, ok := commands[name]; ok {
+			return cmd, true
+		}
+	}
+
+	return Command{}, false
+}
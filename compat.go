@@ -0,0 +1,130 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CompatLevel is the outcome of evaluating a peer version.
+type CompatLevel int
+
+const (
+	CompatOK      CompatLevel = iota // the peer is fully supported
+	CompatWarning                    // the peer works but should be upgraded
+	CompatError                      // the peer can't be talked to
+)
+
+func (l CompatLevel) String() string {
+	switch l {
+	case CompatOK:
+		return "ok"
+	case CompatWarning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+// Default templates of CompatMatrix.
+const (
+	DefaultCompatWarning = "peer version {{.Peer}} is deprecated, {{.AppName}} {{.Self}} supports {{.Compatible}}; please upgrade the peer\n"
+	DefaultCompatError   = "peer version {{.Peer}} is incompatible with {{.AppName}} {{.Self}}, which supports {{.Compatible}}\n"
+)
+
+// CompatMatrix declares which versions of its peers a build can talk to
+// during rolling upgrades. Compatible and Deprecated are constraints as
+// accepted by ParseConstraint: peers satisfying Compatible are fine, peers
+// only satisfying Deprecated still work but cause a warning, anything else is
+// an error.
+//
+// Warning and Error are text/template templates for the messages, rendered
+// with a CompatResult; empty ones mean DefaultCompatWarning and
+// DefaultCompatError.
+type CompatMatrix struct {
+	Compatible string
+	Deprecated string
+	Warning    string
+	Error      string
+}
+
+// CompatResult is the result of CompatMatrix.Evaluate.
+type CompatResult struct {
+	Level      CompatLevel
+	AppName    string
+	Self       string // version of the running binary
+	Peer       string
+	Compatible string
+	Deprecated string
+	Message    string // the rendered warning or error, empty if Level is CompatOK
+}
+
+// Err returns the message as an error if Level is CompatError.
+func (r *CompatResult) Err() error {
+	if r.Level != CompatError {
+		return nil
+	}
+	return errors.New(strings.TrimSpace(r.Message))
+}
+
+// Evaluate checks the version of a peer, typically received in a handshake,
+// against the matrix. It returns an error only if the matrix itself is
+// invalid.
+func (m *CompatMatrix) Evaluate(peer string) (*CompatResult, error) {
+	compatible, err := ParseConstraint(m.Compatible)
+	if err != nil {
+		return nil, err
+	}
+
+	var deprecated *Constraint
+	if m.Deprecated != "" {
+		if deprecated, err = ParseConstraint(m.Deprecated); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &CompatResult{
+		Level:      CompatError,
+		Peer:       peer,
+		Compatible: m.Compatible,
+		Deprecated: m.Deprecated,
+	}
+
+	if d, err := GetDetail(); err == nil {
+		r.AppName, r.Self = d.AppName, d.AppVersion
+	}
+
+	if v, err := Parse(peer); err == nil {
+		switch {
+		case compatible.Check(v):
+			r.Level = CompatOK
+			return r, nil
+		case deprecated != nil && deprecated.Check(v):
+			r.Level = CompatWarning
+		}
+	}
+
+	text, name := m.Error, "error"
+	if text == "" {
+		text = DefaultCompatError
+	}
+	if r.Level == CompatWarning {
+		text, name = m.Warning, "warning"
+		if text == "" {
+			text = DefaultCompatWarning
+		}
+	}
+
+	tmpl, err := parseTemplate(name, text, r)
+	if err != nil {
+		return nil, fmt.Errorf("compat %s template error: %v", name, err)
+	}
+
+	var b strings.Builder
+	if err := executeTemplate(tmpl, r, &b); err != nil {
+		return nil, fmt.Errorf("compat %s template error: %v", name, err)
+	}
+	r.Message = b.String()
+
+	return r, nil
+}
@@ -0,0 +1,32 @@
+package version
+
+import "sync"
+
+var (
+	componentsMu sync.RWMutex
+	components   = map[string]string{}
+)
+
+// RegisterComponent registers the version of a component shipped inside the
+// application alongside the main version, such as the schema version of
+// embedded migrations or the version of a bundled web UI. Registering the
+// same name again replaces its version.
+func RegisterComponent(name, version string) {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+
+	components[name] = version
+}
+
+// Components returns a copy of all registered components, keyed by name.
+func Components() map[string]string {
+	componentsMu.RLock()
+	defer componentsMu.RUnlock()
+
+	m := make(map[string]string, len(components))
+	for name, version := range components {
+		m[name] = version
+	}
+
+	return m
+}
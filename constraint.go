@@ -0,0 +1,256 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint is a parsed version constraint, such as ">=1.4, <2".
+//
+// A constraint is a list of comparisons separated by commas, all of which
+// must be satisfied; alternatives can be given with "||". A comparison is an
+// operator followed by a version, where the operator is one of =, !=, >, >=,
+// <, <=, ~ (same minor version) and ^ (same major version, or same minor
+// version for v0), and defaults to =. The leading v and trailing parts of
+// the version may be omitted: "<2" means "<v2.0.0", and "=1.4" or "1.4.x"
+// means any v1.4 version. "*" matches any version.
+//
+// Pre-release versions are compared by plain semver precedence, so
+// v2.0.0-rc.1 satisfies "<2".
+type Constraint struct {
+	raw  string
+	alts [][]comparison
+}
+
+type comparison struct {
+	op string
+	v  Version
+}
+
+// ParseConstraint parses a version constraint.
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: s}
+
+	for _, alt := range strings.Split(s, "||") {
+		var cmps []comparison
+		for _, term := range strings.Split(alt, ",") {
+			term = strings.TrimSpace(term)
+			if term == "" {
+				return nil, fmt.Errorf("invalid constraint %q: empty comparison", s)
+			}
+
+			parsed, err := parseComparison(term)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constraint %q: %v", s, err)
+			}
+			cmps = append(cmps, parsed...)
+		}
+		c.alts = append(c.alts, cmps)
+	}
+
+	return c, nil
+}
+
+// parseComparison parses a single comparison. Partial versions are expanded
+// into one or two comparisons on full versions.
+func parseComparison(term string) ([]comparison, error) {
+	op := ""
+	for _, o := range []string{">=", "<=", "!=", "==", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(term, o) {
+			op = o
+			break
+		}
+	}
+
+	rest := strings.TrimSpace(term[len(op):])
+	if op == "" || op == "==" {
+		op = "="
+	}
+
+	if rest == "*" {
+		if op != "=" {
+			return nil, fmt.Errorf("bad comparison %q", term)
+		}
+		return nil, nil
+	}
+
+	v, n, err := parsePartial(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	// upper returns the lowest version above the range given by the first
+	// k parts of v.
+	upper := func(k int) Version {
+		switch k {
+		case 1:
+			return Version{Major: v.Major + 1}
+		case 2:
+			return Version{Major: v.Major, Minor: v.Minor + 1}
+		}
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+
+	switch op {
+	case "=":
+		if n == 3 {
+			return []comparison{{"=", v}}, nil
+		}
+		return []comparison{{">=", v}, {"<", upper(n)}}, nil
+	case "!=":
+		if n != 3 {
+			return nil, fmt.Errorf("bad comparison %q: need a full version", term)
+		}
+		return []comparison{{"!=", v}}, nil
+	case ">":
+		if n != 3 {
+			return []comparison{{">=", upper(n)}}, nil
+		}
+	case "<=":
+		if n != 3 {
+			return []comparison{{"<", upper(n)}}, nil
+		}
+	case "~":
+		return []comparison{{">=", v}, {"<", upper(minInt(n, 2))}}, nil
+	case "^":
+		k := 1
+		if v.Major == 0 {
+			k = 2
+		}
+		return []comparison{{">=", v}, {"<", upper(minInt(n, k))}}, nil
+	}
+
+	return []comparison{{op, v}}, nil
+}
+
+// parsePartial parses a version which may lack the leading v, the minor and
+// patch parts, or have them as "x". It returns the number of parts given.
+func parsePartial(s string) (Version, int, error) {
+	s = strings.TrimPrefix(s, "v")
+
+	core := s
+	suffix := ""
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		core, suffix = s[:i], s[i:]
+	}
+
+	parts := strings.Split(core, ".")
+	n := len(parts)
+	for n > 0 && (parts[n-1] == "x" || parts[n-1] == "X" || parts[n-1] == "*") {
+		n--
+	}
+	if n == 0 || n > 3 || (n < 3 && suffix != "") {
+		return Version{}, 0, fmt.Errorf("bad version %q", s)
+	}
+
+	full := parts[:n]
+	for len(full) < 3 {
+		full = append(full, "0")
+	}
+
+	v, err := Parse("v" + strings.Join(full, ".") + suffix)
+	if err != nil {
+		return Version{}, 0, err
+	}
+
+	return *v, n, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Check reports whether v satisfies the constraint.
+func (c *Constraint) Check(v *Version) bool {
+	for _, alt := range c.alts {
+		ok := true
+		for _, cmp := range alt {
+			if !cmp.check(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (cmp comparison) check(v *Version) bool {
+	c := v.Compare(&cmp.v)
+
+	switch cmp.op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+	return false
+}
+
+// String returns the constraint as it was given to ParseConstraint.
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// MismatchError is returned by RequireVersion when the running binary
+// doesn't satisfy the required version.
+type MismatchError struct {
+	App        string // application name
+	Path       string // main package path, for go install
+	Version    string // version of the running binary
+	Constraint string // the required version
+}
+
+func (e *MismatchError) Error() string {
+	what := "version " + e.Version
+	if _, err := Parse(e.Version); err != nil {
+		what = "development build " + e.Version
+	}
+
+	return fmt.Sprintf("%s %s doesn't satisfy the required version %q; "+
+		"install a matching version with `go install %s@<version>`, "+
+		"or update the required version in your configuration",
+		e.App, what, e.Constraint, e.Path)
+}
+
+// RequireVersion checks that the running binary satisfies constraint, which
+// typically comes from a configuration file pinning the version of a tool.
+// On mismatch it returns a *MismatchError which names both versions and how
+// to fix it. Development builds without a semantic version never satisfy
+// a constraint.
+func RequireVersion(constraint string) error {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return err
+	}
+
+	info, ok := readBuildInfo()
+	if !ok {
+		return ErrNoBuildInfo
+	}
+
+	d := newDetail(info)
+	if v, err := Parse(d.AppVersion); err == nil && c.Check(v) {
+		return nil
+	}
+
+	return &MismatchError{
+		App:        d.AppName,
+		Path:       info.Path,
+		Version:    d.AppVersion,
+		Constraint: constraint,
+	}
+}
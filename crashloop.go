@@ -0,0 +1,198 @@
+package version
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// RunStart is a run of the application recorded by StartRun.
+type RunStart struct {
+	RunRecord
+	PID   int  `json:"pid"`
+	Clean bool `json:"clean"` // EndRun was called
+}
+
+const (
+	runsFile = "runs.json"
+	maxRuns  = 100 // runs kept in runsFile
+)
+
+var (
+	runMu    sync.Mutex
+	runStart time.Time // of the running process, set by StartRun
+)
+
+// StartRun records the start of the running binary in the state directory.
+// The run counts as a crash until EndRun records its clean shutdown, see
+// DetectCrashLoop. Call it early in main, and EndRun on the normal exit
+// path. The latest 100 runs are kept. The run is also appended to the
+// journal, if it's on, see SetJournal. Concurrent processes sharing the
+// state directory take turns updating the runs through a lock file.
+func StartRun() error {
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	cur, err := currentRun()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockState(runsFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	runs, err := readRuns()
+	if err != nil {
+		return err
+	}
+
+	runs = append(runs, RunStart{RunRecord: *cur, PID: os.Getpid()})
+	if len(runs) > maxRuns {
+		runs = runs[len(runs)-maxRuns:]
+	}
+
+	if err := writeState(runsFile, runs); err != nil {
+		return err
+	}
+
+	runStart = cur.Time
+
+	return appendJournal(EventStarted)
+}
+
+// EndRun records the clean shutdown of the run recorded by StartRun.
+func EndRun() error {
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	if runStart.IsZero() {
+		return fmt.Errorf("EndRun called without StartRun")
+	}
+
+	unlock, err := lockState(runsFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	runs, err := readRuns()
+	if err != nil {
+		return err
+	}
+
+	for i := range runs {
+		r := &runs[i]
+		if r.PID == os.Getpid() && r.Time.Equal(runStart) {
+			r.Clean = true
+			if err := writeState(runsFile, runs); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	return appendJournal(EventStopped)
+}
+
+// ReadRuns returns the runs recorded by StartRun, oldest first.
+func ReadRuns() ([]RunStart, error) {
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	return readRuns()
+}
+
+func readRuns() ([]RunStart, error) {
+	var runs []RunStart
+	if err := readState(runsFile, &runs); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// CrashLoop is the crash history of a version, see DetectCrashLoop.
+type CrashLoop struct {
+	Version string
+	Crashes int
+	Window  time.Duration
+	Last    time.Time // start of the latest crashed run
+}
+
+func (c *CrashLoop) String() string {
+	times := "times"
+	if c.Crashes == 1 {
+		times = "time"
+	}
+
+	return fmt.Sprintf("%s has crashed %d %s in %s", c.Version, c.Crashes, times, humanWindow(c.Window))
+}
+
+// humanWindow formats a window like "10 minutes", falling back to
+// time.Duration's format when it's not a whole number of minutes or hours.
+func humanWindow(d time.Duration) string {
+	n, unit := int64(d/time.Minute), "minute"
+	switch {
+	case d%time.Minute != 0:
+		return d.String()
+	case d >= 2*time.Hour && d%time.Hour == 0:
+		n, unit = int64(d/time.Hour), "hour"
+	}
+
+	if n != 1 {
+		unit += "s"
+	}
+
+	return fmt.Sprintf("%d %s", n, unit)
+}
+
+// DetectCrashLoop returns how many runs of the running version, recorded
+// by StartRun, started within window and didn't shut down cleanly, not
+// counting the running process or other processes still running. A supervisor or wrapper script can decide
+// on a rollback from it, e.g. with Updater.Rollback:
+//
+//	if loop, err := version.DetectCrashLoop(10 * time.Minute); err == nil && loop.Crashes >= 3 {
+//		log.Printf("%s, rolling back", loop)
+//		...
+//	}
+func DetectCrashLoop(window time.Duration) (*CrashLoop, error) {
+	cur, err := currentRun()
+	if err != nil {
+		return nil, err
+	}
+
+	runMu.Lock()
+	runs, err := readRuns()
+	start := runStart
+	runMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	loop := &CrashLoop{Version: cur.Version, Window: window}
+	since := cur.Time.Add(-window)
+
+	for _, r := range runs {
+		if r.Clean || r.Version != cur.Version || r.Time.Before(since) {
+			continue
+		}
+		if r.PID == os.Getpid() && r.Time.Equal(start) {
+			continue
+		}
+		if r.PID != os.Getpid() && processAlive(r.PID) {
+			// running concurrently, e.g. another instance
+			continue
+		}
+
+		loop.Crashes++
+		if r.Time.After(loop.Last) {
+			loop.Last = r.Time
+		}
+	}
+
+	return loop, nil
+}
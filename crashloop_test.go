@@ -0,0 +1,55 @@
+package version
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestDetectCrashLoopSkipsRunningProcesses(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	// a process which is gone, as a crashed run is
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	if err := exited.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := StartRun(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		runMu.Lock()
+		runStart = time.Time{}
+		runMu.Unlock()
+	})
+
+	runs, err := ReadRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	own := runs[len(runs)-1]
+
+	// the parent, go test, is another instance still running
+	concurrent := own
+	concurrent.PID = os.Getppid()
+	crashed := own
+	crashed.PID = exited.Process.Pid
+	crashed.Time = own.Time.Add(-time.Second)
+
+	if err := writeState(runsFile, []RunStart{crashed, concurrent, own}); err != nil {
+		t.Fatal(err)
+	}
+
+	loop, err := DetectCrashLoop(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loop.Crashes != 1 {
+		t.Errorf("got %d crashes, want 1 of the exited process", loop.Crashes)
+	}
+	if !loop.Last.Equal(crashed.Time) {
+		t.Errorf("last crash at %v, want %v", loop.Last, crashed.Time)
+	}
+}
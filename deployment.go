@@ -0,0 +1,24 @@
+package version
+
+import "os"
+
+// Environment variables GetDetail reads the deployment context from, e.g.
+// set by the deployment manifest:
+//
+//	env:
+//	- name: GO_VERSION_DEPLOYMENT_ID
+//	  value: "deploy-2024-03-01.3"
+//	- name: GO_VERSION_ENVIRONMENT
+//	  value: production
+const (
+	DeploymentIDEnv = "GO_VERSION_DEPLOYMENT_ID"
+	EnvironmentEnv  = "GO_VERSION_ENVIRONMENT"
+)
+
+// setDeployment fills the deployment context of d from the environment of
+// the running process. It only applies to the running binary, not to the
+// binaries inspected by this package.
+func setDeployment(d *Detail) {
+	d.DeploymentID = os.Getenv(DeploymentIDEnv)
+	d.Environment = os.Getenv(EnvironmentEnv)
+}
@@ -0,0 +1,151 @@
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// VersionOf returns the version of the module modulePath linked into the
+// running binary, so that a library can tell its own version independent of
+// the application embedding it:
+//
+//	v, _ := version.VersionOf("github.com/me/mylib")
+//
+// If the module is replaced, the version of the replacement is returned,
+// which is "(devel)" for a replacement by a local directory. If modulePath
+// is the main module, e.g. when running the library's own examples, its
+// version is returned. ok is false if the module is not linked in.
+func VersionOf(modulePath string) (version string, ok bool) {
+	info, ok := readBuildInfo()
+	if !ok {
+		return "", false
+	}
+
+	return versionOf(info, modulePath)
+}
+
+func versionOf(info *debug.BuildInfo, modulePath string) (string, bool) {
+	if info.Main.Path == modulePath {
+		return info.Main.Version, true
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		if dep.Version == "" {
+			return "(devel)", true
+		}
+		return dep.Version, true
+	}
+
+	return "", false
+}
+
+// DependencyError describes a dependency embedded in the binary that
+// violates a required version.
+type DependencyError struct {
+	Path       string // module path of the dependency
+	Version    string // embedded version, after replacements
+	Constraint string // the required version
+}
+
+func (e *DependencyError) Error() string {
+	if e.Version == "" {
+		return fmt.Sprintf("dependency %s is replaced by a local directory, "+
+			"which can't be checked against %q", e.Path, e.Constraint)
+	}
+
+	return fmt.Sprintf("dependency %s %s doesn't satisfy the required version %q",
+		e.Path, e.Version, e.Constraint)
+}
+
+// DependencyErrors is the list of violations returned by AssertDependencies.
+type DependencyErrors []*DependencyError
+
+func (e DependencyErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// AssertDependency checks that the dependency modulePath embedded in the
+// running binary satisfies constraint, e.g.
+//
+//	AssertDependency("golang.org/x/crypto", ">=0.21.0")
+//
+// See AssertDependencies for details.
+func AssertDependency(modulePath, constraint string) error {
+	return AssertDependencies(map[string]string{modulePath: constraint})
+}
+
+// AssertDependencies checks the dependencies embedded in the running binary
+// against required versions keyed by module path, so that security baselines
+// can be enforced by the binary itself, e.g. at startup. It returns
+// DependencyErrors listing every violation, or nil.
+//
+// Dependencies that are not embedded in the binary satisfy any constraint,
+// since they are not shipped. Dependencies replaced by a local directory
+// have no version and never satisfy a constraint.
+func AssertDependencies(constraints map[string]string) error {
+	info, ok := readBuildInfo()
+	if !ok {
+		return ErrNoBuildInfo
+	}
+
+	return checkDependencies(info.Deps, constraints)
+}
+
+func checkDependencies(deps []*debug.Module, constraints map[string]string) error {
+	paths := make([]string, 0, len(constraints))
+	parsed := make(map[string]*Constraint, len(constraints))
+	for path, constraint := range constraints {
+		c, err := ParseConstraint(constraint)
+		if err != nil {
+			return fmt.Errorf("dependency %s: %w", path, err)
+		}
+		paths = append(paths, path)
+		parsed[path] = c
+	}
+	sort.Strings(paths)
+
+	embedded := make(map[string]*debug.Module, len(deps))
+	for _, dep := range deps {
+		embedded[dep.Path] = dep
+	}
+
+	var errs DependencyErrors
+	for _, path := range paths {
+		dep, ok := embedded[path]
+		if !ok {
+			continue
+		}
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+
+		if v, err := Parse(dep.Version); err == nil && parsed[path].Check(v) {
+			continue
+		}
+
+		errs = append(errs, &DependencyError{
+			Path:       path,
+			Version:    dep.Version,
+			Constraint: constraints[path],
+		})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
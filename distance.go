@@ -0,0 +1,156 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// CommitCounter counts the commits made since a tag, i.e. reachable from
+// commit but not from tag. GitCounter counts them in a local clone;
+// GitHubChecker, GitLabChecker and GiteaChecker ask the forge.
+type CommitCounter interface {
+	CommitsSince(ctx context.Context, modulePath, tag, commit string) (int, error)
+}
+
+// Distance is how far a pseudo version is ahead of the tag it's based on.
+type Distance struct {
+	Base    string `json:"base"`
+	Commits int    `json:"commits"`
+}
+
+// String returns the distance like "+3 commits since v1.2.3".
+func (d *Distance) String() string {
+	unit := "commits"
+	if d.Commits == 1 {
+		unit = "commit"
+	}
+	return fmt.Sprintf("+%d %s since %s", d.Commits, unit, d.Base)
+}
+
+// ErrNoBaseTag is returned by EstimateDistance for builds which aren't
+// pseudo versions derived from a tag.
+var ErrNoBaseTag = errors.New("not a pseudo version derived from a tag")
+
+// EstimateDistance counts with counter the commits between the tag a
+// pseudo version is based on and the commit it was built from, and sets
+// d.Distance, which the default detail template shows like "+3 commits
+// since v1.2.3". Releases, untagged branches and development builds get
+// ErrNoBaseTag.
+func (d *Detail) EstimateDistance(ctx context.Context, counter CommitCounter) error {
+	if d.Type != PseudoBaseRelease && d.Type != PseudoBasePreRelease {
+		return ErrNoBaseTag
+	}
+
+	n, err := counter.CommitsSince(ctx, d.ModulePath, d.Tag, d.CommitID)
+	if err != nil {
+		return fmt.Errorf("counting commits since %s: %w", d.Tag, err)
+	}
+
+	d.Distance = &Distance{Base: d.Tag, Commits: n}
+	return nil
+}
+
+// GitCounter is a CommitCounter using git in a local clone of the module.
+type GitCounter struct {
+	Dir string // the clone, default the current directory
+
+	// TagPrefix is prepended to tags, e.g. "sub/" for a module in the
+	// subdirectory sub, whose tags are like sub/v1.2.3.
+	TagPrefix string
+}
+
+// CommitsSince implements CommitCounter.
+func (c *GitCounter) CommitsSince(ctx context.Context, modulePath, tag, commit string) (int, error) {
+	args := []string{"rev-list", "--count", c.TagPrefix + tag + ".." + commit}
+	if c.Dir != "" {
+		args = append([]string{"-C", c.Dir}, args...)
+	}
+
+	out, err := exec.CommandContext(ctx, "git", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return 0, fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return 0, fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+// CommitsSince implements CommitCounter with the compare API of GitHub.
+func (c *GitHubChecker) CommitsSince(ctx context.Context, modulePath, tag, commit string) (int, error) {
+	repoURL, header := c.endpoint(modulePath)
+
+	var r struct {
+		AheadBy int `json:"ahead_by"`
+	}
+	u := repoURL + "/compare/" + escapeRef(subdirOf(modulePath, 2)+tag) + "..." + escapeRef(commit)
+	if err := getJSON(ctx, c.Client, c.CABundle, u, header, &r); err != nil {
+		return 0, err
+	}
+
+	return r.AheadBy, nil
+}
+
+// CommitsSince implements CommitCounter with the compare API of GitLab.
+func (c *GitLabChecker) CommitsSince(ctx context.Context, modulePath, tag, commit string) (int, error) {
+	projectURL, project, header := c.endpoint(modulePath)
+
+	// projects may be nested in groups at any depth, so the subdirectory
+	// of the module is what its path has beyond the project
+	subdir := ""
+	if _, repo := splitRepo(modulePath, -1); strings.HasPrefix(repo, project+"/") {
+		subdir = repo[len(project)+1:] + "/"
+	}
+
+	var r struct {
+		Commits []struct{} `json:"commits"`
+	}
+	u := projectURL + "/repository/compare?straight=false&from=" + url.QueryEscape(subdir+tag) + "&to=" + url.QueryEscape(commit)
+	if err := getJSON(ctx, c.Client, c.CABundle, u, header, &r); err != nil {
+		return 0, err
+	}
+
+	return len(r.Commits), nil
+}
+
+// CommitsSince implements CommitCounter with the compare API of Gitea.
+func (c *GiteaChecker) CommitsSince(ctx context.Context, modulePath, tag, commit string) (int, error) {
+	repoURL, header := c.endpoint(modulePath)
+
+	var r struct {
+		TotalCommits int `json:"total_commits"`
+	}
+	u := repoURL + "/compare/" + escapeRef(subdirOf(modulePath, 2)+tag) + "..." + escapeRef(commit)
+	if err := getJSON(ctx, c.Client, c.CABundle, u, header, &r); err != nil {
+		return 0, err
+	}
+
+	return r.TotalCommits, nil
+}
+
+// subdirOf returns the subdirectory of a module in a repository whose path
+// has n elements, with a trailing slash, as it prefixes the tags of the
+// module: "sub/" for github.com/you/repo/sub/v2.
+func subdirOf(modulePath string, n int) string {
+	elems := strings.Split(modulePath, "/")
+	if k := len(elems); k > 1 && isMajorSuffix(elems[k-1]) {
+		elems = elems[:k-1]
+	}
+	if len(elems) <= n+1 {
+		return ""
+	}
+
+	return strings.Join(elems[n+1:], "/") + "/"
+}
+
+// escapeRef escapes a git ref for a URL path, keeping its slashes.
+func escapeRef(ref string) string {
+	return strings.ReplaceAll(url.PathEscape(ref), "%2F", "/")
+}
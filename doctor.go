@@ -0,0 +1,224 @@
+package version
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// Results of the check of a tool by Doctor.
+const (
+	ToolOK       = "ok"
+	ToolMissing  = "missing"  // not found in the search path
+	ToolUnknown  = "unknown"  // found, but its version can't be read
+	ToolMismatch = "mismatch" // found, but its version doesn't satisfy the pin
+)
+
+// ToolStatus is the result of the check of one pinned tool by Doctor.
+type ToolStatus struct {
+	Tool       string `json:"tool"`
+	Constraint string `json:"constraint"`
+	File       string `json:"file,omitempty"`    // where the tool was found
+	Version    string `json:"version,omitempty"` // version embedded in it
+	Status     string `json:"status"`            // ToolOK, ToolMissing, ToolUnknown or ToolMismatch
+
+	// Shadowed lists the copies of the tool later in the search path, which
+	// never run since File wins, but are often stale leftovers of another
+	// installation.
+	Shadowed []ToolCopy `json:"shadowed,omitempty"`
+}
+
+// ToolCopy is a copy of a tool found by Doctor.
+type ToolCopy struct {
+	File    string `json:"file"`
+	Version string `json:"version,omitempty"` // empty if unknown
+}
+
+// DoctorReport is the result of Doctor. It's marshalled to JSON as is, and
+// rendered as text by Render.
+type DoctorReport struct {
+	Tools []ToolStatus `json:"tools"`
+}
+
+// Doctor locates each pinned tool in searchPath, a list of directories like
+// $PATH, which it defaults to, reads the version embedded in it and checks
+// it against the pin, like a "make doctor" target would:
+//
+//	pins, err := version.ReadPins(".tool-versions")
+//	...
+//	report := version.Doctor(pins, "")
+//	report.Render(os.Stdout)
+//	if !report.OK() {
+//		os.Exit(1)
+//	}
+//
+// Tools pinned by package path are looked up by its last element, and the
+// commands of the Go toolchain, such as go and gofmt, have its version. Only
+// the first match in searchPath is checked, since that's the one that runs;
+// the copies it shadows are listed with their versions.
+func Doctor(pins Pins, searchPath string) *DoctorReport {
+	if searchPath == "" {
+		searchPath = os.Getenv("PATH")
+	}
+
+	r := &DoctorReport{Tools: make([]ToolStatus, 0, len(pins))}
+	for _, pin := range pins {
+		r.Tools = append(r.Tools, checkTool(pin, searchPath))
+	}
+
+	return r
+}
+
+func checkTool(pin Pin, searchPath string) ToolStatus {
+	s := ToolStatus{
+		Tool:       pin.Tool,
+		Constraint: pin.Constraint.String(),
+		Status:     ToolMissing,
+	}
+
+	files := lookPathAll(path.Base(pin.Tool), searchPath)
+	if len(files) == 0 {
+		return s
+	}
+
+	s.File = files[0]
+	for _, file := range files[1:] {
+		s.Shadowed = append(s.Shadowed, ToolCopy{File: file, Version: toolVersion(file)})
+	}
+
+	s.Version = toolVersion(s.File)
+	if s.Version == "" {
+		s.Status = ToolUnknown
+		return s
+	}
+
+	if v, err := Parse(s.Version); err == nil && pin.Constraint.Check(v) {
+		s.Status = ToolOK
+	} else {
+		s.Status = ToolMismatch
+	}
+
+	return s
+}
+
+// toolVersion returns the version embedded in the binary file, or "" if it
+// can't be read.
+func toolVersion(file string) string {
+	report, err := Inspect(file)
+	if err != nil {
+		return ""
+	}
+
+	if strings.HasPrefix(report.Info.Path, "cmd/") && report.Info.GoVersion != "" {
+		// commands of the toolchain, such as go and gofmt, have the version
+		// of the toolchain
+		return "v" + strings.TrimPrefix(report.Info.GoVersion, "go")
+	}
+
+	return report.Info.Main.Version
+}
+
+// lookPathAll returns the executables named name in the directories of
+// searchPath, in order. Directories listed twice are only searched once,
+// and a file found in several directories, e.g. through symlinks, is only
+// returned once.
+func lookPathAll(name, searchPath string) []string {
+	if runtime.GOOS == "windows" && !strings.HasSuffix(strings.ToLower(name), ".exe") {
+		name += ".exe"
+	}
+
+	var (
+		files []string
+		found []os.FileInfo // of files
+	)
+	seen := map[string]bool{}
+	for _, dir := range filepath.SplitList(searchPath) {
+		if dir == "" {
+			dir = "."
+		}
+
+		if seen[filepath.Clean(dir)] {
+			continue
+		}
+		seen[filepath.Clean(dir)] = true
+
+		file := filepath.Join(dir, name)
+		fi, err := os.Stat(file)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		if runtime.GOOS != "windows" && fi.Mode()&0o111 == 0 {
+			continue
+		}
+
+		// e.g. /bin and /usr/bin of merged-/usr systems, where one is a
+		// symlink to the other
+		if sameAsAny(fi, found) {
+			continue
+		}
+
+		files = append(files, file)
+		found = append(found, fi)
+	}
+
+	return files
+}
+
+func sameAsAny(fi os.FileInfo, infos []os.FileInfo) bool {
+	for _, other := range infos {
+		if os.SameFile(fi, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// OK reports whether every pinned tool was found with a matching version.
+func (r *DoctorReport) OK() bool {
+	for _, t := range r.Tools {
+		if t.Status != ToolOK {
+			return false
+		}
+	}
+	return true
+}
+
+// Render writes the report as a table, one line per tool.
+func (r *DoctorReport) Render(w io.Writer) error {
+	ew := &errWriter{w: w}
+
+	tw := tabwriter.NewWriter(ew, 0, 4, 2, ' ', 0)
+	for _, t := range r.Tools {
+		var detail string
+		switch t.Status {
+		case ToolOK:
+			detail = t.Version
+		case ToolMissing:
+			detail = "not found"
+		case ToolUnknown:
+			detail = "version unknown, " + t.File
+		case ToolMismatch:
+			detail = fmt.Sprintf("%s doesn't satisfy %q, %s", t.Version, t.Constraint, t.File)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", t.Status, t.Tool, detail)
+		for _, c := range t.Shadowed {
+			v := c.Version
+			if v == "" {
+				v = "version unknown"
+			}
+			fmt.Fprintf(tw, "\t\tshadows %s, %s\n", v, c.File)
+		}
+	}
+	tw.Flush()
+
+	if ew.err == nil && !r.OK() {
+		fmt.Fprintln(ew, "\nSome tools are missing or outdated, install them with `go install PACKAGE@VERSION`.")
+	}
+
+	return ew.err
+}
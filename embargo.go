@@ -0,0 +1,158 @@
+package version
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"runtime/debug"
+	"strings"
+)
+
+// Embargo settings of white-label builds, set them via -ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/flw-cn/go-version.EmbargoPath=oem.example/router
+//	    -X github.com/flw-cn/go-version.EmbargoTokenHash=$(printf %s "$TOKEN" | sha256sum | cut -c-64)"
+//
+// If EmbargoPath is set, the upstream module path is replaced by it in all
+// build info read by this package, and so in any rendering, JSON export,
+// event or template, and the commit is replaced by EmbargoRevision, or by
+// Redacted if that is empty. The commit of a pseudo version is zeroed. The
+// genuine build info remains available to support engineers through
+// RevealDetail.
+var (
+	EmbargoPath      string // module path shown instead of the upstream one
+	EmbargoRevision  string // revision shown instead of the upstream commit
+	EmbargoTokenHash string // hex SHA-256 of the token accepted by RevealDetail
+)
+
+// ErrNotAuthorized is returned by RevealDetail for a wrong token.
+var ErrNotAuthorized = errors.New("not authorized to reveal the embargoed build info")
+
+// RevealDetail returns the Detail of the running binary without the
+// embargo, if token hashes to EmbargoTokenHash, e.g. for a hidden debug
+// flag of support builds. See RunReveal. Without EmbargoTokenHash, no
+// token is accepted.
+func RevealDetail(token string) (*Detail, error) {
+	if err := checkRevealToken(token); err != nil {
+		return nil, err
+	}
+
+	info, ok := readRedactedBuildInfo()
+	if !ok {
+		return nil, ErrNoBuildInfo
+	}
+
+	d := newDetail(info)
+	setDeployment(d)
+	d.LibraryVersion = LibraryVersion()
+
+	return d, nil
+}
+
+// checkRevealToken returns ErrNotAuthorized unless token hashes to
+// EmbargoTokenHash.
+func checkRevealToken(token string) error {
+	want, err := hex.DecodeString(EmbargoTokenHash)
+	if err != nil || len(want) != sha256.Size {
+		return ErrNotAuthorized
+	}
+	got := sha256.Sum256([]byte(token))
+	if subtle.ConstantTimeCompare(got[:], want) != 1 {
+		return ErrNotAuthorized
+	}
+
+	return nil
+}
+
+// embargoRevision returns the revision shown by embargoed builds, or ""
+// without an embargo.
+func embargoRevision() string {
+	switch {
+	case EmbargoPath == "":
+		return ""
+	case EmbargoRevision != "":
+		return EmbargoRevision
+	}
+	return Redacted
+}
+
+// applyEmbargo returns a copy of info with the upstream module path and
+// commit replaced, see EmbargoPath.
+func applyEmbargo(info *debug.BuildInfo) *debug.BuildInfo {
+	revision := embargoRevision()
+	if revision == "" {
+		return info
+	}
+
+	upstream := info.Main.Path
+
+	// the commit in all lengths it's commonly abbreviated to, longest first
+	var commits []string
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			for _, n := range []int{len(s.Value), 12, 7} {
+				if n <= len(s.Value) && n > 0 {
+					commits = append(commits, s.Value[:n])
+				}
+			}
+		}
+	}
+	if v, err := Parse(info.Main.Version); err == nil {
+		if _, _, _, commit := pseudoBase(v); commit != "" {
+			commits = append(commits, commit)
+		}
+	}
+
+	e := *info
+	e.Path = replacePathPrefix(info.Path, upstream, EmbargoPath)
+	e.Main.Path = EmbargoPath
+	e.Main.Sum = ""
+	for _, c := range commits {
+		// zeroed, so that the version is still valid
+		e.Main.Version = strings.ReplaceAll(e.Main.Version, c, strings.Repeat("0", len(c)))
+	}
+
+	// the path and commit also leak through -ldflags like
+	// -X github.com/you/app/internal.commit=...
+	e.Settings = make([]debug.BuildSetting, 0, len(info.Settings))
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			s.Value = revision
+		} else {
+			if upstream != "" {
+				s.Value = strings.ReplaceAll(s.Value, upstream, EmbargoPath)
+			}
+			for _, c := range commits {
+				s.Value = strings.ReplaceAll(s.Value, c, revision)
+			}
+		}
+		e.Settings = append(e.Settings, s)
+	}
+
+	return &e
+}
+
+// replacePathPrefix replaces the module path prefix of pkg, e.g. of the
+// main package path.
+func replacePathPrefix(pkg, prefix, replacement string) string {
+	if prefix == "" {
+		return pkg
+	}
+	if pkg == prefix {
+		return replacement
+	}
+	if strings.HasPrefix(pkg, prefix+"/") {
+		return replacement + pkg[len(prefix):]
+	}
+	return pkg
+}
+
+// commitRevision returns the revision to show for the commit of a version,
+// which is zeroed by an embargo.
+func commitRevision(commit string) string {
+	if r := embargoRevision(); r != "" && strings.Trim(commit, "0") == "" {
+		return r
+	}
+	return commit
+}
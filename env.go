@@ -0,0 +1,62 @@
+package version
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// ExportEnv sets PREFIX_VERSION, PREFIX_COMMIT and PREFIX_BUILD_DATE in the
+// environment of the process, and returns them, so that child processes
+// and entrypoint scripts inherit the identity of the build without parsing
+// the output of --version. The prefix defaults to "APP". The build date is
+// the time of the last commit in RFC 3339, as Go doesn't record the time
+// of the build itself; it and the commit are empty if unknown. See also
+// RunPrintEnv.
+func ExportEnv(prefix string) (map[string]string, error) {
+	env, err := buildEnv(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range env {
+		if err := os.Setenv(k, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return env, nil
+}
+
+func buildEnv(prefix string) (map[string]string, error) {
+	d, err := GetDetail()
+	if err != nil {
+		return nil, err
+	}
+
+	if prefix == "" {
+		prefix = "APP"
+	}
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+
+	commit := d.Revision
+	if commit == "unknown" {
+		commit = ""
+	}
+
+	date := ""
+	if !d.LastCommit.IsZero() {
+		date = d.LastCommit.UTC().Format(time.RFC3339)
+	}
+
+	return map[string]string{
+		prefix + "VERSION":    d.AppVersion,
+		prefix + "COMMIT":     commit,
+		prefix + "BUILD_DATE": date,
+	}, nil
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
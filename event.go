@@ -0,0 +1,123 @@
+package version
+
+import (
+	"encoding/binary"
+	"os"
+	"time"
+)
+
+// EventSchemaVersion is the schema version of Event. It is increased when
+// fields are added; existing fields are never removed or renumbered.
+const EventSchemaVersion = 1
+
+// Types of Event.
+const (
+	EventStarted = "binary.started" // the binary starts
+	EventStopped = "binary.stopped" // the binary shuts down cleanly, see EndRun
+)
+
+// Event is a single, well-formed record describing a running binary, meant
+// to be pushed into analytics pipelines such as Kafka. It encodes to JSON
+// with encoding/json, and to protobuf with MarshalProto following the schema
+// in event.proto.
+type Event struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	Type          string    `json:"type"`
+	Time          time.Time `json:"time"`
+	App           string    `json:"app"`
+	Module        string    `json:"module"`
+	Version       string    `json:"version"`
+	GoVersion     string    `json:"goVersion"`
+	Revision      string    `json:"revision"`
+	Dirty         bool      `json:"dirty"`
+	Platform      string    `json:"platform"`
+	Hostname      string    `json:"hostname,omitempty"`
+	PID           int       `json:"pid"`
+}
+
+// StartEvent returns an EventStarted event for the running binary.
+func StartEvent() (*Event, error) {
+	d, err := GetDetail()
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &Event{
+		SchemaVersion: EventSchemaVersion,
+		Type:          EventStarted,
+		Time:          time.Now(),
+		App:           d.AppName,
+		Module:        d.ModulePath,
+		Version:       d.AppVersion,
+		GoVersion:     d.GoVersion,
+		Revision:      d.Revision,
+		Dirty:         d.IsDirty,
+		Platform:      d.Platform,
+		Hostname:      hostname,
+		PID:           os.Getpid(),
+	}, nil
+}
+
+// MarshalProto encodes e in protobuf wire format, see event.proto.
+func (e *Event) MarshalProto() []byte {
+	var b []byte
+
+	b = appendVarintField(b, 1, uint64(e.SchemaVersion))
+	b = appendStringField(b, 2, e.Type)
+	if !e.Time.IsZero() {
+		var ts []byte
+		ts = appendVarintField(ts, 1, uint64(e.Time.Unix()))
+		ts = appendVarintField(ts, 2, uint64(e.Time.Nanosecond()))
+		b = appendBytesField(b, 3, ts)
+	}
+	b = appendStringField(b, 4, e.App)
+	b = appendStringField(b, 5, e.Module)
+	b = appendStringField(b, 6, e.Version)
+	b = appendStringField(b, 7, e.GoVersion)
+	b = appendStringField(b, 8, e.Revision)
+	if e.Dirty {
+		b = appendVarintField(b, 9, 1)
+	}
+	b = appendStringField(b, 10, e.Platform)
+	b = appendStringField(b, 11, e.Hostname)
+	b = appendVarintField(b, 12, uint64(e.PID))
+
+	return b
+}
+
+// Protobuf wire types, see https://protobuf.dev/programming-guides/encoding/
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// appendVarintField appends a varint field, omitting zero values as proto3
+// does for scalars.
+func appendVarintField(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendUvarint(b, uint64(num)<<3|wireVarint)
+	return appendUvarint(b, v)
+}
+
+func appendStringField(b []byte, num int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendBytesField(b, num, []byte(s))
+}
+
+func appendBytesField(b []byte, num int, v []byte) []byte {
+	b = appendUvarint(b, uint64(num)<<3|wireBytes)
+	b = appendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
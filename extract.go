@@ -0,0 +1,84 @@
+package version
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// FoundVersion is a version found in text by ExtractVersions.
+type FoundVersion struct {
+	Line      int            // 1-based line number
+	Text      string         // the token as it appears in the text
+	Version   *Version       // nil for a watermark of a non-semver build, e.g. (devel)
+	Watermark *WatermarkInfo // non-nil if the version comes from a watermark
+}
+
+var versionTokenPattern = regexp.MustCompile(
+	`v?[0-9]+\.[0-9]+\.[0-9]+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?`)
+
+// ExtractVersions scans r, typically a log or a generated file, for
+// watermarks produced by Watermark and for semver-looking tokens such as
+// v1.2.3 or 1.2.3-rc.1, and returns them in order of appearance. Versions
+// inside a watermark are only reported as part of it.
+func ExtractVersions(r io.Reader) ([]FoundVersion, error) {
+	var found []FoundVersion
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+
+		wmStart, wmEnd := -1, -1
+		if loc := watermarkPattern.FindStringSubmatchIndex(line); loc != nil {
+			m := make([]string, len(loc)/2)
+			for i := range m {
+				if loc[2*i] >= 0 {
+					m[i] = line[loc[2*i]:loc[2*i+1]]
+				}
+			}
+			if wm, err := watermarkFromMatch(m); err == nil {
+				wmStart, wmEnd = loc[0], loc[1]
+				fv := FoundVersion{Line: n, Text: m[0], Watermark: wm}
+				fv.Version, _ = Parse(wm.Version)
+				found = append(found, fv)
+			}
+		}
+
+		for _, loc := range versionTokenPattern.FindAllStringIndex(line, -1) {
+			start, end := loc[0], loc[1]
+			if start < wmEnd && end > wmStart {
+				continue
+			}
+
+			// skip parts of longer tokens, like IP addresses or 1.2.3.4
+			if start > 0 && (isAlnum(line[start-1]) || line[start-1] == '.') {
+				continue
+			}
+			if end < len(line) && (isAlnum(line[end]) || line[end] == '.' && end+1 < len(line) && isAlnum(line[end+1])) {
+				continue
+			}
+
+			text := strings.TrimRight(line[start:end], ".-")
+			s := text
+			if !strings.HasPrefix(s, "v") {
+				s = "v" + s
+			}
+
+			v, err := Parse(s)
+			if err != nil {
+				continue
+			}
+
+			found = append(found, FoundVersion{Line: n, Text: text, Version: v})
+		}
+	}
+
+	return found, sc.Err()
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,82 @@
+package version
+
+import (
+	"sort"
+	"sync"
+)
+
+var (
+	featuresMu sync.RWMutex
+	features   = map[string]string{}
+)
+
+// RegisterFeature declares that feature is available since version, e.g.
+//
+//	version.RegisterFeature("bulk-api", "v1.3.0")
+//
+// so that peers can derive each other's capabilities from their versions
+// instead of exchanging bespoke capability flags. It panics if since is not a
+// valid semantic version.
+func RegisterFeature(feature, since string) {
+	if _, err := Parse(since); err != nil {
+		panic("version: RegisterFeature " + feature + ": " + err.Error())
+	}
+
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+
+	features[feature] = since
+}
+
+// HasFeature reports whether a peer running version has feature. Like
+// CompatMatrix, it doesn't trust versions which aren't valid semver, such as
+// an empty version or one of a development build: they have no features.
+// Unknown features are never available.
+func HasFeature(version, feature string) bool {
+	featuresMu.RLock()
+	since, ok := features[feature]
+	featuresMu.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	v, err := Parse(version)
+	if err != nil {
+		return false
+	}
+
+	s, _ := Parse(since)
+	return v.Compare(s) >= 0
+}
+
+// FeaturesOf returns the sorted names of the features available in version.
+func FeaturesOf(version string) []string {
+	featuresMu.RLock()
+	names := make([]string, 0, len(features))
+	for name := range features {
+		names = append(names, name)
+	}
+	featuresMu.RUnlock()
+
+	var available []string
+	for _, name := range names {
+		if HasFeature(version, name) {
+			available = append(available, name)
+		}
+	}
+	sort.Strings(available)
+
+	return available
+}
+
+// AdvertisedFeatures returns the features of the running binary, to be sent
+// to peers in handshakes.
+func AdvertisedFeatures() []string {
+	info, ok := readBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	return FeaturesOf(info.Main.Version)
+}
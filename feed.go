@@ -0,0 +1,146 @@
+package version
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// UpdateFeed is a self-hosted update feed: the latest release of each
+// release channel, in a JSON shape that Homebrew livecheck reads with its
+// :json strategy and FeedChecker reads for in-app update checks:
+//
+//	{
+//	  "latest": {"version": "1.4.2", "tag": "v1.4.2", "time": "..."},
+//	  "channels": {
+//	    "stable": {"version": "1.4.2", "tag": "v1.4.2", "time": "..."},
+//	    "prerelease": {"version": "1.5.0-rc.1", "tag": "v1.5.0-rc.1", "time": "..."}
+//	  }
+//	}
+//
+// The livecheck block of a formula is then:
+//
+//	livecheck do
+//	  url "https://example.com/app/releases.json"
+//	  strategy :json do |json|
+//	    json["latest"]["version"]
+//	  end
+//	end
+type UpdateFeed struct {
+	Latest   *FeedEntry            `json:"latest,omitempty"` // the latest stable release
+	Channels map[string]*FeedEntry `json:"channels"`         // keyed by "stable", "prerelease" or "nightly"
+}
+
+// FeedEntry is a release in an UpdateFeed.
+type FeedEntry struct {
+	Version string    `json:"version"` // without the leading v, as package managers expect
+	Tag     string    `json:"tag"`     // the module version
+	Time    time.Time `json:"time"`
+}
+
+// NewUpdateFeed builds the feed of the given releases, e.g. the versions
+// listed by ProxyClient.Versions or the tags of a repository. Pseudo
+// versions and invalid versions are ignored.
+func NewUpdateFeed(releases []ReleaseInfo) *UpdateFeed {
+	feed := &UpdateFeed{Channels: map[string]*FeedEntry{}}
+
+	for _, r := range releases {
+		channel := releaseChannel(r.Version)
+		if channel == "devel" {
+			continue
+		}
+
+		if e := feed.Channels[channel]; e == nil || Compare(r.Version, e.Tag) > 0 {
+			feed.Channels[channel] = &FeedEntry{
+				Version: strings.TrimPrefix(r.Version, "v"),
+				Tag:     r.Version,
+				Time:    r.Time,
+			}
+		}
+	}
+
+	feed.Latest = feed.Channels["stable"]
+
+	return feed
+}
+
+// releaseChannel returns the release channel of a version, see
+// UpdateResult.Channel.
+func releaseChannel(v string) string {
+	if v == "" {
+		return "devel"
+	}
+
+	mod := GetAppVersion(v)
+	if mod == nil {
+		return "devel"
+	}
+
+	return channelOf(&Detail{Brief: Brief{AppVersion: v}, ModVersion: *mod})
+}
+
+// FeedHandler returns an http.Handler serving the UpdateFeed of the
+// releases returned by source, which is called on every request; cache
+// its result if it's expensive.
+func FeedHandler(source func(ctx context.Context) ([]ReleaseInfo, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		releases, err := source(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		var b bytes.Buffer
+		if err := json.NewEncoder(&b).Encode(NewUpdateFeed(releases)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b.Bytes())
+	})
+}
+
+// FeedChecker is an UpdateChecker reading a self-hosted UpdateFeed, e.g.
+// one served by FeedHandler.
+type FeedChecker struct {
+	URL     string       // of the feed
+	Channel string       // release channel to follow, default "stable"
+	Client  *http.Client // default RemoteOptions.Client
+}
+
+// Latest implements UpdateChecker. The module path is not used, the feed
+// is the one of the application.
+func (c *FeedChecker) Latest(ctx context.Context, modulePath string) (*ReleaseInfo, error) {
+	data, err := fetchCached(ctx, c.Client, c.URL, http.Header{"Accept": {"application/json"}})
+	if err != nil {
+		return nil, err
+	}
+
+	var feed UpdateFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("invalid update feed %s: %w", c.URL, err)
+	}
+
+	channel := c.Channel
+	if channel == "" {
+		channel = "stable"
+	}
+
+	e := feed.Channels[channel]
+	if e == nil {
+		return nil, fmt.Errorf("no %s release in the update feed %s", channel, c.URL)
+	}
+
+	return &ReleaseInfo{Version: e.Tag, Time: e.Time}, nil
+}
@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly) || tinygo
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly tinygo
+
+package version
+
+import (
+	"os"
+)
+
+// fileIDOf can't identify files on this platform (notably Windows, where
+// os.FileInfo doesn't carry the file index), so callers fall back to
+// os.SameFile.
+func fileIDOf(fi os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
@@ -0,0 +1,21 @@
+//go:build (linux || darwin || freebsd || netbsd || openbsd || dragonfly) && !tinygo
+// +build linux darwin freebsd netbsd openbsd dragonfly
+// +build !tinygo
+
+package version
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIDOf returns the device and inode of the file fi was returned for by
+// os.Stat, which are equal for hard links and symlinks to the same file.
+func fileIDOf(fi os.FileInfo) (fileID, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
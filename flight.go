@@ -0,0 +1,168 @@
+package version
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLookupTTL is how long the latest version found by an update check
+// is reused in-process, see SetLookupTTL.
+const DefaultLookupTTL = time.Minute
+
+// lookupTTL is the TTL in nanoseconds, negative disables caching.
+var lookupTTL = int64(DefaultLookupTTL)
+
+// SetLookupTTL sets how long the latest version found by an update check is
+// reused in-process. Concurrent checks of the same module with equal
+// checkers are coalesced into one lookup regardless, so that per-request
+// "am I outdated?" logic doesn't stampede the proxy; failures are shared
+// with the concurrent callers but not cached. Zero means DefaultLookupTTL,
+// a negative ttl disables the cache.
+func SetLookupTTL(ttl time.Duration) {
+	if ttl == 0 {
+		ttl = DefaultLookupTTL
+	}
+	atomic.StoreInt64(&lookupTTL, int64(ttl))
+}
+
+// lookupKey identifies the lookups of a module by equal checkers.
+// Checkers are usually recreated for each check, e.g. by CheckUpdate, so
+// the known ones are told apart by their type and configuration; others
+// by their identity.
+type lookupKey struct {
+	typ    string
+	base   string      // where the known checkers look
+	id     interface{} // other checkers
+	module string
+}
+
+// lookup is a lookup in flight.
+type lookup struct {
+	done    chan struct{} // closed when the lookup is done
+	release *ReleaseInfo
+	err     error
+}
+
+// recentLookup is the result of a successful lookup, reused until it
+// expires.
+type recentLookup struct {
+	release *ReleaseInfo
+	expires time.Time
+}
+
+var lookups = struct {
+	sync.Mutex
+	inFlight map[lookupKey]*lookup
+	recent   map[lookupKey]recentLookup
+}{
+	inFlight: make(map[lookupKey]*lookup),
+	recent:   make(map[lookupKey]recentLookup),
+}
+
+// newLookupKey returns the key of the lookups of modulePath by checker, or
+// false if they can't be shared.
+func newLookupKey(checker UpdateChecker, modulePath string) (lookupKey, bool) {
+	key := lookupKey{typ: fmt.Sprintf("%T", checker), module: modulePath}
+
+	switch c := checker.(type) {
+	case *ProxyClient:
+		key.base = c.Proxy + " " + c.NoProxy
+	case *GitHubChecker:
+		key.base = c.BaseURL + " " + c.Repo
+	case *GitLabChecker:
+		key.base = c.BaseURL + " " + c.Project
+	case *GiteaChecker:
+		key.base = c.BaseURL + " " + c.Repo
+	case *AtomChecker:
+		key.base = c.URL
+	case *FeedChecker:
+		key.base = c.URL + " " + c.Channel
+	case *SignedChecker:
+		// a result verified with other keys mustn't be shared
+		key.base = c.URL
+		for _, k := range c.Keys {
+			key.base += " " + hex.EncodeToString(k)
+		}
+	default:
+		if checker == nil || !reflect.TypeOf(checker).Comparable() {
+			return lookupKey{}, false
+		}
+		key.id = checker
+	}
+
+	return key, true
+}
+
+// latestOnce returns checker.Latest(ctx, modulePath), coalescing concurrent
+// calls and reusing recent results.
+func latestOnce(ctx context.Context, checker UpdateChecker, modulePath string) (*ReleaseInfo, error) {
+	key, ok := newLookupKey(checker, modulePath)
+	if !ok {
+		return checker.Latest(ctx, modulePath)
+	}
+
+	now := time.Now()
+
+	lookups.Lock()
+	for k, r := range lookups.recent {
+		if now.After(r.expires) {
+			delete(lookups.recent, k)
+		}
+	}
+	if r, ok := lookups.recent[key]; ok {
+		lookups.Unlock()
+		return copyRelease(r.release), nil
+	}
+
+	l, ok := lookups.inFlight[key]
+	if !ok {
+		l = &lookup{done: make(chan struct{})}
+		lookups.inFlight[key] = l
+		lookups.Unlock()
+
+		func() {
+			defer close(l.done)
+			defer func() {
+				lookups.Lock()
+				defer lookups.Unlock()
+
+				delete(lookups.inFlight, key)
+				if ttl := time.Duration(atomic.LoadInt64(&lookupTTL)); l.err == nil && ttl > 0 {
+					lookups.recent[key] = recentLookup{l.release, time.Now().Add(ttl)}
+				}
+			}()
+			l.release, l.err = checker.Latest(ctx, modulePath)
+		}()
+
+		return copyRelease(l.release), l.err
+	}
+	lookups.Unlock()
+
+	select {
+	case <-l.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if (errors.Is(l.err, context.Canceled) || errors.Is(l.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+		// the context of the caller doing the lookup ended, not ours
+		return latestOnce(ctx, checker, modulePath)
+	}
+
+	return copyRelease(l.release), l.err
+}
+
+// copyRelease keeps callers from modifying the shared result.
+func copyRelease(r *ReleaseInfo) *ReleaseInfo {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	return &c
+}
@@ -0,0 +1,105 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// countingChecker counts the lookups reaching it, which block until
+// release is closed. Its fields are pointers so that it keys the same
+// lookup however often it's called.
+type countingChecker struct {
+	calls   *int32
+	release chan struct{}
+	err     error
+}
+
+func (c countingChecker) Latest(ctx context.Context, modulePath string) (*ReleaseInfo, error) {
+	atomic.AddInt32(c.calls, 1)
+	<-c.release
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &ReleaseInfo{Version: "v1.2.3"}, nil
+}
+
+func TestLatestOnceCoalesces(t *testing.T) {
+	checker := countingChecker{calls: new(int32), release: make(chan struct{})}
+
+	const callers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := latestOnce(context.Background(), checker, "example.com/flight/coalesce")
+			if err == nil && r.Version != "v1.2.3" {
+				err = errors.New("got version " + r.Version)
+			}
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	close(checker.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if n := atomic.LoadInt32(checker.calls); n != 1 {
+		t.Errorf("got %d upstream calls, want 1", n)
+	}
+}
+
+func TestLatestOnceDoesNotCacheFailures(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	checker := countingChecker{calls: new(int32), release: release, err: errors.New("unavailable")}
+
+	for i := 0; i < 2; i++ {
+		if _, err := latestOnce(context.Background(), checker, "example.com/flight/failure"); err == nil {
+			t.Fatal("got no error, want one")
+		}
+	}
+	if n := atomic.LoadInt32(checker.calls); n != 2 {
+		t.Errorf("got %d upstream calls, want 2", n)
+	}
+}
+
+func TestLookupKeyOfEqualCheckers(t *testing.T) {
+	a, okA := newLookupKey(&ProxyClient{Proxy: "https://proxy.example.com"}, "example.com/app")
+	b, okB := newLookupKey(&ProxyClient{Proxy: "https://proxy.example.com"}, "example.com/app")
+	if !okA || !okB || a != b {
+		t.Errorf("equal checkers have the keys %+v and %+v, want the same", a, b)
+	}
+
+	c, _ := newLookupKey(&ProxyClient{Proxy: "https://other.example.com"}, "example.com/app")
+	if a == c {
+		t.Errorf("checkers of different proxies have the same key %+v", a)
+	}
+}
+
+func TestLatestOnceForgetsFinishedLookups(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	checker := countingChecker{calls: new(int32), release: release}
+
+	if _, err := latestOnce(context.Background(), checker, "example.com/flight/forget"); err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := newLookupKey(checker, "example.com/flight/forget")
+	lookups.Lock()
+	_, inFlight := lookups.inFlight[key]
+	lookups.Unlock()
+	if inFlight {
+		t.Error("the finished lookup is still in flight")
+	}
+}
@@ -0,0 +1,269 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GitHubChecker is an UpdateChecker using the releases of a GitHub or GitHub
+// Enterprise repository. Drafts and pre-releases are ignored.
+type GitHubChecker struct {
+	BaseURL  string       // API URL, default https://api.github.com, or https://HOST/api/v3 for GitHub Enterprise
+	Repo     string       // owner/name, default derived from the module path
+	CABundle string       // PEM file with additional CA certificates
+	Client   *http.Client // default RemoteOptions.Client
+
+	// Token defaults to the password in netrc for the API host. $GITHUB_TOKEN
+	// and $GH_TOKEN take precedence, but only for api.github.com or an
+	// explicit BaseURL, so that they don't leak to the host of a module path.
+	Token string
+}
+
+// Latest implements UpdateChecker.
+func (c *GitHubChecker) Latest(ctx context.Context, modulePath string) (*ReleaseInfo, error) {
+	repoURL, header := c.endpoint(modulePath)
+
+	var r forgeRelease
+	if err := getJSON(ctx, c.Client, c.CABundle, repoURL+"/releases/latest", header, &r); err != nil {
+		return nil, err
+	}
+
+	return r.info(modulePath)
+}
+
+// endpoint returns the API URL of the repository and the request headers.
+func (c *GitHubChecker) endpoint(modulePath string) (string, http.Header) {
+	host, repo := splitRepo(modulePath, 2)
+	if c.Repo != "" {
+		repo = c.Repo
+	}
+
+	base := c.BaseURL
+	if base == "" {
+		base = "https://api.github.com"
+		if host != "github.com" {
+			base = "https://" + host + "/api/v3"
+		}
+	}
+
+	header := http.Header{"Accept": {"application/vnd.github+json"}}
+	token := c.Token
+	if token == "" {
+		var envs []string
+		if c.BaseURL != "" || base == "https://api.github.com" {
+			envs = []string{"GITHUB_TOKEN", "GH_TOKEN"}
+		}
+		token = lookupToken(envs, hostOf(base), host)
+	}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	return strings.TrimSuffix(base, "/") + "/repos/" + repo, header
+}
+
+// GitLabChecker is an UpdateChecker using the releases of a GitLab project.
+// Upcoming releases and pre-releases are ignored.
+type GitLabChecker struct {
+	BaseURL  string       // default https://HOST of the module path
+	Project  string       // project path, default derived from the module path
+	CABundle string       // PEM file with additional CA certificates
+	Client   *http.Client // default RemoteOptions.Client
+
+	// Token defaults to the password in netrc for the API host. $GITLAB_TOKEN
+	// takes precedence, but only for gitlab.com or an explicit BaseURL, so
+	// that it doesn't leak to the host of a module path.
+	Token string
+}
+
+// Latest implements UpdateChecker.
+func (c *GitLabChecker) Latest(ctx context.Context, modulePath string) (*ReleaseInfo, error) {
+	projectURL, project, header := c.endpoint(modulePath)
+
+	var releases []forgeRelease
+	if err := getJSON(ctx, c.Client, c.CABundle, projectURL+"/releases?per_page=100", header, &releases); err != nil {
+		return nil, err
+	}
+
+	var best *ReleaseInfo
+	for _, r := range releases {
+		if r.UpcomingRelease {
+			continue
+		}
+		info, err := r.info(modulePath)
+		if err != nil || isPrerelease(info.Version) {
+			continue
+		}
+		if best == nil || Compare(info.Version, best.Version) > 0 {
+			best = info
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no release found in GitLab project %s", project)
+	}
+
+	return best, nil
+}
+
+// endpoint returns the API URL of the project, its path, and the request
+// headers.
+func (c *GitLabChecker) endpoint(modulePath string) (string, string, http.Header) {
+	host, project := splitRepo(modulePath, -1)
+	if c.Project != "" {
+		project = c.Project
+	}
+
+	base := c.BaseURL
+	if base == "" {
+		base = "https://" + host
+	}
+
+	header := http.Header{}
+	token := c.Token
+	if token == "" {
+		var envs []string
+		if c.BaseURL != "" || base == "https://gitlab.com" {
+			envs = []string{"GITLAB_TOKEN"}
+		}
+		token = lookupToken(envs, hostOf(base), host)
+	}
+	if token != "" {
+		header.Set("PRIVATE-TOKEN", token)
+	}
+
+	return strings.TrimSuffix(base, "/") + "/api/v4/projects/" + url.PathEscape(project), project, header
+}
+
+// GiteaChecker is an UpdateChecker using the releases of a Gitea or Forgejo
+// repository. Drafts and pre-releases are ignored.
+type GiteaChecker struct {
+	BaseURL  string       // default https://HOST of the module path
+	Repo     string       // owner/name, default derived from the module path
+	CABundle string       // PEM file with additional CA certificates
+	Client   *http.Client // default RemoteOptions.Client
+
+	// Token defaults to the password in netrc for the API host. $GITEA_TOKEN
+	// takes precedence, but only for an explicit BaseURL, as there is no
+	// public instance it could be meant for.
+	Token string
+}
+
+// Latest implements UpdateChecker.
+func (c *GiteaChecker) Latest(ctx context.Context, modulePath string) (*ReleaseInfo, error) {
+	repoURL, header := c.endpoint(modulePath)
+
+	var r forgeRelease
+	if err := getJSON(ctx, c.Client, c.CABundle, repoURL+"/releases/latest", header, &r); err != nil {
+		return nil, err
+	}
+
+	return r.info(modulePath)
+}
+
+// endpoint returns the API URL of the repository and the request headers.
+func (c *GiteaChecker) endpoint(modulePath string) (string, http.Header) {
+	host, repo := splitRepo(modulePath, 2)
+	if c.Repo != "" {
+		repo = c.Repo
+	}
+
+	base := c.BaseURL
+	if base == "" {
+		base = "https://" + host
+	}
+
+	header := http.Header{}
+	token := c.Token
+	if token == "" {
+		var envs []string
+		if c.BaseURL != "" {
+			envs = []string{"GITEA_TOKEN"}
+		}
+		token = lookupToken(envs, hostOf(base), host)
+	}
+	if token != "" {
+		header.Set("Authorization", "token "+token)
+	}
+
+	return strings.TrimSuffix(base, "/") + "/api/v1/repos/" + repo, header
+}
+
+// forgeRelease is the subset of a release common to the forge APIs.
+type forgeRelease struct {
+	TagName         string    `json:"tag_name"`
+	PublishedAt     time.Time `json:"published_at"`
+	ReleasedAt      time.Time `json:"released_at"` // GitLab
+	UpcomingRelease bool      `json:"upcoming_release"`
+}
+
+// info converts r to a ReleaseInfo. Tags of modules in subdirectories, like
+// sub/v1.2.3, lose their prefix, and tags without the leading v get one.
+func (r *forgeRelease) info(modulePath string) (*ReleaseInfo, error) {
+	tag := r.TagName
+	if i := strings.LastIndex(tag, "/"); i >= 0 {
+		tag = tag[i+1:]
+	}
+	if !strings.HasPrefix(tag, "v") {
+		tag = "v" + tag
+	}
+
+	if _, err := Parse(tag); err != nil {
+		return nil, fmt.Errorf("release %q of %s: %w", r.TagName, modulePath, err)
+	}
+
+	t := r.PublishedAt
+	if t.IsZero() {
+		t = r.ReleasedAt
+	}
+
+	return &ReleaseInfo{Version: tag, Time: t}, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, caBundle, url string, header http.Header, v interface{}) error {
+	client, err := clientWithCA(client, caBundle)
+	if err != nil {
+		return err
+	}
+
+	data, err := fetchCached(ctx, client, url, header)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// splitRepo splits a module path into its host and the repository path. The
+// repository path has at most n elements (all of them if n < 0), without
+// a major version suffix such as /v2.
+func splitRepo(modulePath string, n int) (host, repo string) {
+	elems := strings.Split(modulePath, "/")
+	host, elems = elems[0], elems[1:]
+
+	if k := len(elems); k > 1 && isMajorSuffix(elems[k-1]) {
+		elems = elems[:k-1]
+	}
+	if n >= 0 && len(elems) > n {
+		elems = elems[:n]
+	}
+
+	return host, strings.Join(elems, "/")
+}
+
+func isMajorSuffix(elem string) bool {
+	return len(elem) > 1 && elem[0] == 'v' && isNumeric(elem[1:])
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
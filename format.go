@@ -0,0 +1,361 @@
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Formatter renders version information in some output format.
+type Formatter interface {
+	Render(d Detail, w io.Writer) error
+}
+
+// FormatterFunc is an adapter to allow the use of ordinary functions as
+// Formatters.
+type FormatterFunc func(d Detail, w io.Writer) error
+
+// Render implements Formatter.
+func (f FormatterFunc) Render(d Detail, w io.Writer) error {
+	return f(d, w)
+}
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = map[string]Formatter{
+		"text":   TextFormatter{},
+		"json":   JSONFormatter{},
+		"yaml":   FormatterFunc(renderYAML),
+		"logfmt": FormatterFunc(renderLogfmt),
+		"line":   FormatterFunc(renderLine),
+	}
+)
+
+// RegisterFormatter makes a Formatter available by name, so that options
+// like `--output <name>` of downstream CLIs can be powered by this package.
+// The built-in formatters are text, json, yaml, logfmt and line, see
+// FormatLine; registering one of these names replaces it.
+func RegisterFormatter(name string, f Formatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+
+	formatters[name] = f
+}
+
+// LookupFormatter returns the Formatter registered by name.
+func LookupFormatter(name string) (Formatter, bool) {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+
+	f, ok := formatters[name]
+	return f, ok
+}
+
+// FormatterNames returns the sorted names of all registered Formatters.
+func FormatterNames() []string {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Render renders the version information of the running binary to w with
+// the Formatter registered by name.
+func Render(name string, w io.Writer) error {
+	f, ok := LookupFormatter(name)
+	if !ok {
+		return fmt.Errorf("unknown output format %q, valid formats are: %s",
+			name, strings.Join(FormatterNames(), ", "))
+	}
+
+	d, err := GetDetail()
+	if err != nil {
+		return err
+	}
+
+	return f.Render(*d, w)
+}
+
+// JSONFormatter renders Detail as indented JSON. If Fields is not nil, only
+// the top-level fields it names are rendered, e.g. PublicFields for
+// public-facing endpoints.
+type JSONFormatter struct {
+	Fields []string
+}
+
+// PublicFields is a field allowlist exposing only what is safe to publish.
+var PublicFields = []string{"appVersion", "goVersion"}
+
+// Render implements Formatter.
+func (f JSONFormatter) Render(d Detail, w io.Writer) error {
+	if f.Fields == nil {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	data, err = filterFields(data, f.Fields)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	if err := json.Indent(&b, data, "", "  "); err != nil {
+		return err
+	}
+	b.WriteByte('\n')
+
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// filterFields keeps the members of the JSON object data named by fields,
+// in their original order.
+func filterFields(data []byte, fields []string) ([]byte, error) {
+	allowed := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		allowed[f] = true
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil { // {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		name, _ := key.(string)
+		if !allowed[name] {
+			continue
+		}
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(name)
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+
+	return b.Bytes(), nil
+}
+
+// member is a member of a JSON object.
+type member struct {
+	key   string
+	value interface{}
+}
+
+// toTree converts v to its JSON representation, as a tree of []member for
+// objects (keeping the order of fields), []interface{} for arrays, and
+// string, json.Number, bool or nil for scalars. Deriving the other formats
+// from JSON keeps their field names and contents consistent.
+func toTree(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	return decodeTree(dec)
+}
+
+func decodeTree(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := []member{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key.(string), value})
+		}
+		_, err = dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			value, err := decodeTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		_, err = dec.Token()
+		return arr, err
+	}
+
+	return tok, nil
+}
+
+func renderYAML(d Detail, w io.Writer) error {
+	tree, err := toTree(d)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	writeYAML(&b, tree, 0)
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+func writeYAML(b *bytes.Buffer, v interface{}, indent int) {
+	pad := strings.Repeat(" ", indent)
+
+	switch v := v.(type) {
+	case []member:
+		for _, m := range v {
+			b.WriteString(pad + yamlKey(m.key) + ":")
+			writeYAMLChild(b, m.value, indent)
+		}
+	case []interface{}:
+		for _, e := range v {
+			b.WriteString(pad + "-")
+			writeYAMLChild(b, e, indent)
+		}
+	}
+}
+
+// writeYAMLChild writes the value following a key or a dash.
+func writeYAMLChild(b *bytes.Buffer, v interface{}, indent int) {
+	switch c := v.(type) {
+	case []member:
+		if len(c) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+	case []interface{}:
+		if len(c) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+	default:
+		b.WriteString(" " + scalarString(v, true) + "\n")
+		return
+	}
+
+	b.WriteString("\n")
+	writeYAML(b, v, indent+2)
+}
+
+// yamlKey quotes key unless it's a plain identifier.
+func yamlKey(key string) string {
+	for _, c := range key {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '-' || c == '.' || c == '/') {
+			return scalarString(key, true)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
+
+// scalarString formats a JSON scalar. Strings are always quoted if
+// alwaysQuote is true, which is valid and unambiguous YAML; otherwise they
+// are only quoted when needed, as in logfmt.
+func scalarString(v interface{}, alwaysQuote bool) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if alwaysQuote {
+			data, _ := json.Marshal(v)
+			return string(data)
+		}
+		if v == "" || strings.ContainsAny(v, " =\"\\\t\r\n") {
+			return strconv.Quote(v)
+		}
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+func renderLogfmt(d Detail, w io.Writer) error {
+	tree, err := toTree(d)
+	if err != nil {
+		return err
+	}
+
+	var pairs []string
+	flatten(tree, "", func(key string, value interface{}) {
+		key = strings.Map(func(r rune) rune {
+			if r <= ' ' || r == '=' || r == '"' {
+				return '_'
+			}
+			return r
+		}, key)
+		pairs = append(pairs, key+"="+scalarString(value, false))
+	})
+
+	_, err = io.WriteString(w, strings.Join(pairs, " ")+"\n")
+	return err
+}
+
+// flatten calls fn for each scalar in tree, with keys of nested values
+// joined by dots.
+func flatten(tree interface{}, prefix string, fn func(key string, value interface{})) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := tree.(type) {
+	case []member:
+		for _, m := range v {
+			flatten(m.value, join(m.key), fn)
+		}
+	case []interface{}:
+		for i, e := range v {
+			flatten(e, join(strconv.Itoa(i)), fn)
+		}
+	default:
+		fn(prefix, v)
+	}
+}
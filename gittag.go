@@ -0,0 +1,99 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagOptions configures ParseGitTagWith.
+type TagOptions struct {
+	// Prefixes are the prefixes of the tags of interest, e.g. "release/"
+	// or "myapp-" in a monorepo tagging several applications. If set, the
+	// tag must start with one of them, which is stripped; the rest must be
+	// a version, with or without the leading v. If empty, the version is
+	// searched for after the last '/', '-', '_' or '@' it can start at.
+	Prefixes []string
+}
+
+// ParseGitTag parses a raw git tag of a common shape, e.g. v1.2.3,
+// 1.2.3, release/1.2.3, myapp-v1.2.3 or refs/tags/v1.2.3, for release
+// tooling reading tags from git rather than from a module proxy. See
+// ParseGitTagWith.
+func ParseGitTag(tag string) (*Version, error) {
+	return ParseGitTagWith(tag, TagOptions{})
+}
+
+// ParseGitTagWith is ParseGitTag with options.
+func ParseGitTagWith(tag string, opts TagOptions) (*Version, error) {
+	name := strings.TrimPrefix(strings.TrimSpace(tag), "refs/tags/")
+
+	if len(opts.Prefixes) > 0 {
+		for _, p := range opts.Prefixes {
+			if rest := strings.TrimPrefix(name, p); rest != name {
+				if v, err := parseArg(rest); err == nil {
+					return v, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%w: tag %q has none of the prefixes %s",
+			ErrInvalidVersion, tag, strings.Join(opts.Prefixes, ", "))
+	}
+
+	// the first candidate which parses wins, so that a pre-release with a
+	// dash, like myapp-1.2.3-rc.1, isn't cut at its dash
+	for i := 0; i < len(name); i++ {
+		if i > 0 && !strings.ContainsRune("/-_@", rune(name[i-1])) {
+			continue
+		}
+		if v, err := parseArg(name[i:]); err == nil {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no version found in tag %q", ErrInvalidVersion, tag)
+}
+
+// SequenceError is returned by ValidateSequence for a tag which would
+// break the sequence of releases.
+type SequenceError struct {
+	Tag      string
+	Existing string // the published tag it collides with or doesn't exceed
+}
+
+func (e *SequenceError) Error() string {
+	if Compare(e.Tag, e.Existing) == 0 {
+		return fmt.Sprintf("tag %s duplicates the published tag %s", e.Tag, e.Existing)
+	}
+
+	return fmt.Sprintf("tag %s is lower than the published tag %s of the same major version", e.Tag, e.Existing)
+}
+
+// ValidateSequence checks that newTag may follow the published tags: it
+// must be strictly greater than all of them with the same major version,
+// and so not a duplicate either, even with other build metadata. Older
+// major versions may still get patch releases. Tags are parsed with
+// ParseGitTag; tags which aren't versions are ignored. On failure it
+// returns a *SequenceError naming the highest conflicting tag.
+func ValidateSequence(tags []string, newTag string) error {
+	nv, err := ParseGitTag(newTag)
+	if err != nil {
+		return err
+	}
+
+	var highest *Version
+	for _, tag := range tags {
+		v, err := ParseGitTag(tag)
+		if err != nil || v.Major != nv.Major {
+			continue
+		}
+		if highest == nil || v.Compare(highest) > 0 {
+			highest = v
+		}
+	}
+
+	if highest != nil && nv.Compare(highest) <= 0 {
+		return &SequenceError{Tag: nv.String(), Existing: highest.String()}
+	}
+
+	return nil
+}
@@ -1,3 +1,5 @@
 module github.com/flw-cn/go-version
 
-go 1.16
+go 1.18
+
+require golang.org/x/mod v0.17.0
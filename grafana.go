@@ -0,0 +1,228 @@
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Targets served by GrafanaHandler.
+const (
+	GrafanaVersions = "versions" // table of the journal events
+	GrafanaCurrent  = "current"  // table of the running version
+	GrafanaStarts   = "starts"   // time series of the starts, one per version
+)
+
+// GrafanaHandler returns an http.Handler implementing the API of the
+// Grafana JSON datasource plugin (simpod-json-datasource) over the run
+// journal of the host, see SetJournal, so that version timelines can be
+// charted per host without an exporter:
+//
+//	mux.Handle("/grafana/", http.StripPrefix("/grafana", version.GrafanaHandler()))
+//
+// The targets are GrafanaVersions, GrafanaCurrent and GrafanaStarts, and
+// annotations mark the starts of a new version.
+func GrafanaHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "/" + strings.TrimLeft(r.URL.Path, "/")
+
+		if path == "/" {
+			// connection test
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req grafanaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && path != "/search" && path != "/metrics" {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var resp interface{}
+		var err error
+		switch path {
+		case "/search", "/metrics":
+			resp = grafanaTargets()
+		case "/query":
+			resp, err = grafanaQuery(&req)
+		case "/annotations":
+			resp, err = grafanaAnnotations(&req)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var b bytes.Buffer
+		if err := json.NewEncoder(&b).Encode(resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b.Bytes())
+	})
+}
+
+// grafanaRequest is the body of /query and /annotations requests.
+type grafanaRequest struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	Targets []struct {
+		Target string `json:"target"`
+	} `json:"targets"`
+	Annotation json.RawMessage `json:"annotation,omitempty"`
+}
+
+type grafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+type grafanaTable struct {
+	Type    string          `json:"type"`
+	Columns []grafanaColumn `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+type grafanaSeries struct {
+	Target     string     `json:"target"`
+	Datapoints [][2]int64 `json:"datapoints"` // value, Unix milliseconds
+}
+
+type grafanaAnnotation struct {
+	Annotation json.RawMessage `json:"annotation,omitempty"`
+	Time       int64           `json:"time"` // Unix milliseconds
+	Title      string          `json:"title"`
+	Text       string          `json:"text"`
+	Tags       []string        `json:"tags"`
+}
+
+func grafanaTargets() []string {
+	return []string{GrafanaVersions, GrafanaCurrent, GrafanaStarts}
+}
+
+// journalRange returns the journal events within the range of req.
+func journalRange(req *grafanaRequest) ([]Event, error) {
+	events, err := ReadJournal(req.Range.From)
+	if err != nil {
+		return nil, err
+	}
+
+	if to := req.Range.To; !to.IsZero() {
+		i := sort.Search(len(events), func(i int) bool { return events[i].Time.After(to) })
+		events = events[:i]
+	}
+
+	return events, nil
+}
+
+func grafanaQuery(req *grafanaRequest) ([]interface{}, error) {
+	events, err := journalRange(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := []interface{}{}
+	for _, t := range req.Targets {
+		switch t.Target {
+		case GrafanaVersions:
+			table := &grafanaTable{
+				Type: "table",
+				Columns: []grafanaColumn{
+					{"Time", "time"}, {"Event", "string"}, {"Version", "string"},
+					{"Revision", "string"}, {"Host", "string"}, {"PID", "number"},
+				},
+				Rows: [][]interface{}{},
+			}
+			for _, e := range events {
+				table.Rows = append(table.Rows, []interface{}{
+					e.Time.UnixNano() / 1e6, e.Type, e.Version, e.Revision, e.Hostname, e.PID,
+				})
+			}
+			resp = append(resp, table)
+
+		case GrafanaCurrent:
+			d, err := GetDetail()
+			if err != nil {
+				return nil, err
+			}
+			hostname, _ := os.Hostname()
+			resp = append(resp, &grafanaTable{
+				Type: "table",
+				Columns: []grafanaColumn{
+					{"Version", "string"}, {"Revision", "string"},
+					{"Go", "string"}, {"Host", "string"},
+				},
+				Rows: [][]interface{}{{d.AppVersion, d.Revision, d.GoVersion, hostname}},
+			})
+
+		case GrafanaStarts:
+			byVersion := map[string]*grafanaSeries{}
+			for _, e := range events {
+				if e.Type != EventStarted {
+					continue
+				}
+				s := byVersion[e.Version]
+				if s == nil {
+					s = &grafanaSeries{Target: e.Version, Datapoints: [][2]int64{}}
+					byVersion[e.Version] = s
+					resp = append(resp, s)
+				}
+				s.Datapoints = append(s.Datapoints, [2]int64{1, e.Time.UnixNano() / 1e6})
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+// grafanaAnnotations marks the starts of a version different from the
+// previous start.
+func grafanaAnnotations(req *grafanaRequest) ([]grafanaAnnotation, error) {
+	// read from the start, to know the version before the range
+	events, err := ReadJournal(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := []grafanaAnnotation{}
+	prev := ""
+	for _, e := range events {
+		if e.Type != EventStarted {
+			continue
+		}
+		changed := prev != "" && e.Version != prev
+		old := prev
+		prev = e.Version
+
+		if !changed || e.Time.Before(req.Range.From) || !req.Range.To.IsZero() && e.Time.After(req.Range.To) {
+			continue
+		}
+
+		resp = append(resp, grafanaAnnotation{
+			Annotation: req.Annotation,
+			Time:       e.Time.UnixNano() / 1e6,
+			Title:      "Version changed on " + e.Hostname,
+			Text:       old + " → " + e.Version,
+			Tags:       []string{"version", e.Version},
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,155 @@
+package version
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+)
+
+// Formats of RenderDependencyGraph.
+const (
+	GraphDOT     = "dot"     // Graphviz, e.g. `app version --deps-graph | dot -Tsvg`
+	GraphMermaid = "mermaid" // Mermaid flowchart, e.g. for Markdown documents
+)
+
+// RenderDependencyGraph writes the dependencies embedded in the running
+// binary as a graph in the given format, GraphDOT or GraphMermaid.
+func RenderDependencyGraph(format string, w io.Writer) error {
+	info, ok := readBuildInfo()
+	if !ok {
+		return ErrNoBuildInfo
+	}
+
+	return RenderDependencyGraphOf(info, format, w)
+}
+
+// RenderDependencyGraphOf writes the dependencies listed in info as a graph,
+// e.g. of a binary read by Inspect. Build info only records which modules
+// were linked in, not who requires whom, so every dependency hangs off the
+// highlighted main module; replaced modules point to their replacement with
+// a dashed edge.
+func RenderDependencyGraphOf(info *debug.BuildInfo, format string, w io.Writer) error {
+	var g graphWriter
+	switch format {
+	case GraphDOT:
+		g = &dotWriter{}
+	case GraphMermaid:
+		g = &mermaidWriter{}
+	default:
+		return fmt.Errorf("unknown graph format %q, valid formats are: %s, %s",
+			format, GraphDOT, GraphMermaid)
+	}
+
+	ew := &errWriter{w: w}
+	g.begin(ew, moduleLabel(&info.Main, info.Path))
+	for _, dep := range info.Deps {
+		g.dependency(ew, moduleLabel(dep, ""))
+		if dep.Replace != nil {
+			g.replacement(ew, moduleLabel(dep, ""), moduleLabel(dep.Replace, ""))
+		}
+	}
+	g.end(ew)
+
+	return ew.err
+}
+
+func moduleLabel(m *debug.Module, fallback string) string {
+	p := m.Path
+	if p == "" {
+		p = fallback
+	}
+	if m.Version == "" {
+		return p
+	}
+	return p + "@" + m.Version
+}
+
+type graphWriter interface {
+	begin(w io.Writer, main string)
+	dependency(w io.Writer, dep string)
+	replacement(w io.Writer, dep, replace string)
+	end(w io.Writer)
+}
+
+type dotWriter struct {
+	main string
+}
+
+func (g *dotWriter) begin(w io.Writer, main string) {
+	g.main = dotQuote(main)
+	fmt.Fprintf(w, "digraph dependencies {\n")
+	fmt.Fprintf(w, "\trankdir=LR;\n")
+	fmt.Fprintf(w, "\tnode [shape=box];\n")
+	fmt.Fprintf(w, "\t%s [style=\"filled,bold\", fillcolor=lightblue];\n", g.main)
+}
+
+func (g *dotWriter) dependency(w io.Writer, dep string) {
+	fmt.Fprintf(w, "\t%s -> %s;\n", g.main, dotQuote(dep))
+}
+
+func (g *dotWriter) replacement(w io.Writer, dep, replace string) {
+	fmt.Fprintf(w, "\t%s -> %s [style=dashed, label=\"replaced by\"];\n",
+		dotQuote(dep), dotQuote(replace))
+}
+
+func (g *dotWriter) end(w io.Writer) {
+	fmt.Fprintf(w, "}\n")
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// mermaidWriter numbers the nodes, since module paths are not valid
+// Mermaid node ids.
+type mermaidWriter struct {
+	ids map[string]string
+}
+
+func (g *mermaidWriter) begin(w io.Writer, main string) {
+	g.ids = make(map[string]string)
+	fmt.Fprintf(w, "graph LR\n")
+	fmt.Fprintf(w, "\t%s:::main\n", g.node(main))
+}
+
+func (g *mermaidWriter) dependency(w io.Writer, dep string) {
+	fmt.Fprintf(w, "\tn0 --> %s\n", g.node(dep))
+}
+
+func (g *mermaidWriter) replacement(w io.Writer, dep, replace string) {
+	fmt.Fprintf(w, "\t%s -.->|replaced by| %s\n", g.node(dep), g.node(replace))
+}
+
+func (g *mermaidWriter) end(w io.Writer) {
+	fmt.Fprintf(w, "\tclassDef main fill:#add8e6,stroke-width:2px\n")
+}
+
+// node returns the id of the node labelled label, with the label attached
+// the first time it is referenced.
+func (g *mermaidWriter) node(label string) string {
+	if id, ok := g.ids[label]; ok {
+		return id
+	}
+
+	id := fmt.Sprintf("n%d", len(g.ids))
+	g.ids[label] = id
+
+	return id + `["` + strings.ReplaceAll(label, `"`, "#quot;") + `"]`
+}
+
+// errWriter remembers the first write error, so that callers writing many
+// small pieces can check it once at the end.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.w.Write(p)
+	e.err = err
+	return n, err
+}
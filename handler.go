@@ -0,0 +1,46 @@
+package version
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// HandlerOptions configures Handler.
+type HandlerOptions struct {
+	// Fields is an allowlist of the top-level JSON fields to expose, nil
+	// means all. Use PublicFields on public endpoints and keep the full
+	// detail on an internal one:
+	//
+	//	public.Handle("/version", version.Handler(version.HandlerOptions{Fields: version.PublicFields}))
+	//	internal.Handle("/debug/version", version.Handler(version.HandlerOptions{}))
+	Fields []string
+}
+
+// Handler returns an http.Handler serving the version of the running binary
+// as JSON, see JSONFormatter.
+func Handler(opts HandlerOptions) http.Handler {
+	f := JSONFormatter{Fields: opts.Fields}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		d, err := GetDetail()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var b bytes.Buffer
+		if err := f.Render(*d, &b); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b.Bytes())
+	})
+}
@@ -0,0 +1,50 @@
+package version
+
+import (
+	"strings"
+)
+
+// HelpFooter returns a one-line footer for the help output of a command,
+// such as "myapp v1.2.3 (go1.22.1) - https://github.com/me/myapp", so that
+// help text and version output always agree. The homepage is the module
+// path, or the one set by SetDisplayModule.
+//
+// With cobra, append it to the help template of the root command:
+//
+//	cmd.SetHelpTemplate(cmd.HelpTemplate() + "\n" + version.HelpFooter() + "\n")
+//
+// With urfave/cli, append it to the help templates:
+//
+//	cli.AppHelpTemplate += "\n" + version.HelpFooter() + "\n"
+//
+// Braces are escaped, so the footer is safe to embed into those text
+// templates. HelpFooter returns an empty string without build info.
+func HelpFooter() string {
+	d, err := GetDetail()
+	if err != nil {
+		return ""
+	}
+
+	footer := d.AppName + " " + d.AppVersion + " (" + d.GoVersion + ")"
+	if d.ModulePath != "" {
+		footer += " - " + homepage(d.ModulePath)
+	}
+
+	return escapeTemplate(footer)
+}
+
+// homepage turns a module path into a URL, unless it already is one.
+func homepage(modulePath string) string {
+	if strings.Contains(modulePath, "://") {
+		return modulePath
+	}
+	return "https://" + modulePath
+}
+
+// escapeTemplate escapes the action delimiters of text/template in s.
+func escapeTemplate(s string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return strings.ReplaceAll(s, "{{", `{{"{{"}}`)
+}
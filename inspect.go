@@ -0,0 +1,153 @@
+package version
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"time"
+)
+
+// Confidence tells how much the information in a BinaryReport can be trusted.
+type Confidence int
+
+const (
+	ConfidenceNone   Confidence = iota // nothing useful was found
+	ConfidenceLow                      // guessed from version-like strings
+	ConfidenceMedium                   // recovered from remnants of build info
+	ConfidenceHigh                     // read from intact build info
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	default:
+		return "none"
+	}
+}
+
+// BinaryReport represents the version information found in a binary file.
+type BinaryReport struct {
+	File       string   // the path the binary was inspected by
+	RealPath   string   // File with symlinks resolved
+	Links      []string // other paths of the same file found by ScanDir, symlinks and hard links
+	Info       *debug.BuildInfo
+	Confidence Confidence
+	Anomalies  []Anomaly // suspicious combinations in Info, see Anomaly
+}
+
+// Inspect reads the version information from the Go binary at file.
+//
+// Binaries built by old toolchains or mangled by some packers and strippers
+// have no readable build info. For them Inspect falls back to scanning the
+// file for remnants of build info and version-like strings, and tells how
+// reliable the result is via BinaryReport.Confidence. Fields which can't be
+// recovered are left empty.
+func Inspect(file string) (*BinaryReport, error) {
+	realPath, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BinaryReport{
+		File:     file,
+		RealPath: realPath,
+		Info:     &debug.BuildInfo{},
+	}
+
+	info, err := buildinfo.ReadFile(file)
+	if err == nil {
+		report.Info = info
+		report.Confidence = ConfidenceHigh
+		report.Anomalies = findAnomalies(info, time.Now())
+		return report, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	scanBinary(report, data)
+	report.Anomalies = findAnomalies(report.Info, time.Now())
+
+	return report, nil
+}
+
+var (
+	buildInfoMagic = []byte("\xff Go buildinf:")
+	modInfoStart   = []byte("0w\xaf\x0c\x92t\b\x02A\xe1\xc1\a\xe6\xd6\x18\xe6")
+	modInfoEnd     = []byte("\xf92C1\x86\x18 r\x00\x82B\x10A\x16\xd8\xf2")
+
+	goVersionPattern = regexp.MustCompile(`go1\.[0-9]+(\.[0-9]+|rc[0-9]+|beta[0-9]+)?`)
+	modLinePattern   = regexp.MustCompile(`mod\t([^\t\n\x00]+)\t(v[0-9][^\t\n\x00]*)`)
+	semverPattern    = regexp.MustCompile(`v[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?`)
+)
+
+// scanBinary fills report with whatever can be recovered from raw data.
+func scanBinary(report *BinaryReport, data []byte) {
+	info := report.Info
+
+	// Since Go 1.18 the Go version follows the build info header directly.
+	if i := bytes.Index(data, buildInfoMagic); i >= 0 && len(data) > i+32 && data[i+15]&2 != 0 {
+		n, size := binary.Uvarint(data[i+32:])
+		start := i + 32 + size
+		if size > 0 && n < 64 && start+int(n) <= len(data) {
+			info.GoVersion = string(data[start : start+int(n)])
+		}
+	}
+
+	if info.GoVersion == "" {
+		if m := goVersionPattern.Find(data); m != nil {
+			info.GoVersion = string(m)
+		}
+	}
+
+	// The module information is stored as text between two sentinels.
+	if i := bytes.Index(data, modInfoStart); i >= 0 {
+		rest := data[i+len(modInfoStart):]
+		if j := bytes.Index(rest, modInfoEnd); j >= 0 {
+			if bi, err := debug.ParseBuildInfo(string(rest[:j])); err == nil {
+				if bi.GoVersion == "" {
+					bi.GoVersion = info.GoVersion
+				}
+				report.Info = bi
+				report.Confidence = ConfidenceMedium
+				return
+			}
+		}
+	}
+
+	if m := modLinePattern.FindSubmatch(data); m != nil {
+		info.Main = debug.Module{Path: string(m[1]), Version: string(m[2])}
+		info.Path = info.Main.Path
+		report.Confidence = ConfidenceMedium
+		return
+	}
+
+	// The last resort: take the most frequent semver-like string.
+	counts := map[string]int{}
+	best := ""
+	for _, m := range semverPattern.FindAll(data, -1) {
+		v := string(m)
+		counts[v]++
+		if counts[v] > counts[best] {
+			best = v
+		}
+	}
+
+	if best != "" {
+		info.Main.Version = best
+		report.Confidence = ConfidenceLow
+	} else if info.GoVersion != "" {
+		report.Confidence = ConfidenceLow
+	}
+}
@@ -0,0 +1,113 @@
+package version
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+const journalFile = "journal.jsonl"
+
+var journalOn int32
+
+// SetJournal switches the run journal, which is off by default. When on,
+// StartRun and EndRun append an EventStarted and EventStopped event to
+// journal.jsonl in the state directory, one JSON object per line, so that
+// a host keeps a local history of the builds which ran on it, e.g. for
+// forensics. The journal is never truncated; rotate it with the usual log
+// rotation tools.
+func SetJournal(on bool) {
+	atomic.StoreInt32(&journalOn, boolToInt32(on))
+}
+
+// appendJournal appends an event of type typ for the running binary to the
+// journal, if it's on.
+func appendJournal(typ string) error {
+	if atomic.LoadInt32(&journalOn) == 0 {
+		return nil
+	}
+
+	e, err := StartEvent()
+	if err != nil {
+		return err
+	}
+	e.Type = typ
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	dir, err := StateDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	// a single write, so that lines of concurrent processes don't mix
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// ReadJournal returns the events of the journal of this host since the
+// given time, oldest first; a zero time means all of them. See SetJournal.
+func ReadJournal(since time.Time) ([]Event, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(dir, journalFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	events, err := ParseJournal(f)
+	if err != nil {
+		return nil, err
+	}
+
+	i := 0
+	for i < len(events) && events[i].Time.Before(since) {
+		i++
+	}
+
+	return events[i:], nil
+}
+
+// ParseJournal reads a journal, e.g. one collected from another host.
+// Lines which can't be decoded, like a line cut short by a power failure,
+// are skipped.
+func ParseJournal(r io.Reader) ([]Event, error) {
+	var events []Event
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil || e.Type == "" {
+			continue
+		}
+		events = append(events, e)
+	}
+
+	return events, sc.Err()
+}
@@ -0,0 +1,43 @@
+package version
+
+import (
+	_ "embed" // for the JSON Schema
+	"encoding/json"
+)
+
+// OutputSchemaVersion is the version of the JSON and YAML output of Detail,
+// reported as its schemaVersion field. Like EventSchemaVersion, it follows
+// a compatibility policy which lets fleet tooling read the output of any
+// version of this package:
+//
+//   - new fields may be added, which increases the schema version;
+//   - fields are never removed, and never change their meaning or type;
+//   - a renamed field keeps being emitted under its old name too, as a
+//     deprecated field of the Go struct with the old JSON name.
+//
+// Readers should therefore ignore unknown fields, as ParseDetail does.
+const OutputSchemaVersion = 4
+
+//go:embed detail.schema.json
+var jsonSchema []byte
+
+// JSONSchema returns the JSON Schema describing the JSON and YAML output of
+// Detail, so that version reports collected from many tools can be
+// validated. The schema is also published as detail.schema.json at the
+// root of this module.
+func JSONSchema() []byte {
+	return append([]byte(nil), jsonSchema...)
+}
+
+// ParseDetail decodes a Detail from the JSON output of any version of this
+// package. Fields unknown to this version are ignored, and fields missing
+// from older versions are left zero; SchemaVersion tells which version
+// produced the report, 0 meaning one from before schema versioning.
+func ParseDetail(data []byte) (*Detail, error) {
+	var d Detail
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
@@ -0,0 +1,17 @@
+package version
+
+import "reflect"
+
+// libraryPath is the module path of this package, as linked into the
+// binary, which also holds for forks.
+var libraryPath = reflect.TypeOf(Version{}).PkgPath()
+
+// LibraryVersion returns the version of this package linked into the
+// running binary, e.g. v1.4.0, "(devel)" if replaced by a local directory,
+// or "" if unknown, so that bug reports against it can tell which build of
+// the library produced the output. It's also reported as libraryVersion in
+// the JSON and YAML output of Detail. See VersionOf.
+func LibraryVersion() string {
+	v, _ := VersionOf(libraryPath)
+	return v
+}
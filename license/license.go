@@ -0,0 +1,263 @@
+// Package license finds the licenses of the modules embedded in a Go binary
+// and produces a NOTICE-style report of them for legal review.
+//
+// License texts are looked up in the local module cache first, and then in
+// the module zips served by the module proxy (see version.ProxyClient). Each
+// text is classified into an SPDX identifier by a small set of well-known
+// phrases, which covers the licenses commonly found in Go modules:
+//
+//	report, err := license.ForBinary(ctx, "/usr/local/bin/myapp")
+//	if err != nil {
+//	    return err
+//	}
+//	report.WriteNotice(os.Stdout)
+package license
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	version "github.com/flw-cn/go-version"
+)
+
+// Unknown is the identifier of license texts that could not be classified.
+const Unknown = "unknown"
+
+// Module describes the license of one module embedded in a binary.
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	License string `json:"license"`        // SPDX identifier, or Unknown
+	File    string `json:"file,omitempty"` // name of the license file
+	Text    string `json:"-"`              // content of the license file
+	Err     error  `json:"-"`              // why no license file was found
+}
+
+// Report lists the licenses of the main module and the dependencies of a
+// binary.
+type Report struct {
+	File    string    `json:"file,omitempty"`
+	Main    *Module   `json:"main,omitempty"`
+	Modules []*Module `json:"modules"`
+}
+
+// Resolver looks up license files of modules.
+type Resolver struct {
+	// ModCache is the module cache to search first. It defaults to
+	// $GOMODCACHE, or $GOPATH/pkg/mod.
+	ModCache string
+
+	// Proxy downloads module zips that are not in the module cache. It
+	// defaults to version.NewProxyClient(); set NoDownload to disable it.
+	// Downloads are subject to the timeout set by version.SetRemoteOptions,
+	// which may need raising for large modules.
+	Proxy      *version.ProxyClient
+	NoDownload bool
+}
+
+// ForBinary reports the licenses of the modules embedded in file, using a
+// default Resolver.
+func ForBinary(ctx context.Context, file string) (*Report, error) {
+	return (&Resolver{}).ForBinary(ctx, file)
+}
+
+// ForBinary reports the licenses of the modules embedded in file.
+func (r *Resolver) ForBinary(ctx context.Context, file string) (*Report, error) {
+	br, err := version.Inspect(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if br.Info == nil {
+		return nil, fmt.Errorf("%s: no module information found", file)
+	}
+
+	report := r.ForBuildInfo(ctx, br.Info)
+	report.File = file
+
+	return report, nil
+}
+
+// ForBuildInfo reports the licenses of the modules listed in info. The main
+// module is only looked up if it has a version, i.e. was built by
+// `go install module@version`.
+func (r *Resolver) ForBuildInfo(ctx context.Context, info *debug.BuildInfo) *Report {
+	report := &Report{}
+
+	if info.Main.Path != "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		report.Main = r.Lookup(ctx, info.Main.Path, info.Main.Version)
+	}
+
+	for _, dep := range info.Deps {
+		mod := dep
+		if dep.Replace != nil {
+			mod = dep.Replace
+		}
+		if mod.Version == "" {
+			// replaced by a local directory, nothing to look up
+			report.Modules = append(report.Modules, &Module{
+				Path:    dep.Path,
+				License: Unknown,
+				Err:     fmt.Errorf("replaced by local directory %s", mod.Path),
+			})
+			continue
+		}
+		report.Modules = append(report.Modules, r.Lookup(ctx, mod.Path, mod.Version))
+	}
+
+	sort.Slice(report.Modules, func(i, j int) bool {
+		return report.Modules[i].Path < report.Modules[j].Path
+	})
+
+	return report
+}
+
+// Lookup finds and classifies the license of one module version.
+func (r *Resolver) Lookup(ctx context.Context, modulePath, modVersion string) *Module {
+	m := &Module{Path: modulePath, Version: modVersion, License: Unknown}
+
+	name, text, err := r.fromModCache(modulePath, modVersion)
+	if err != nil && !r.NoDownload {
+		name, text, err = r.fromProxy(ctx, modulePath, modVersion)
+	}
+	if err != nil {
+		m.Err = err
+		return m
+	}
+
+	m.File = name
+	m.Text = text
+	m.License = Identify(text)
+
+	return m
+}
+
+func (r *Resolver) fromModCache(modulePath, modVersion string) (string, string, error) {
+	cache := r.ModCache
+	if cache == "" {
+		cache = modCacheDir()
+	}
+	if cache == "" {
+		return "", "", errors.New("module cache not found")
+	}
+
+	escapedPath, err := version.EscapePath(modulePath)
+	if err != nil {
+		return "", "", err
+	}
+	escapedVersion, err := version.EscapePath(modVersion)
+	if err != nil {
+		return "", "", err
+	}
+
+	dir := filepath.Join(cache, filepath.FromSlash(escapedPath)+"@"+escapedVersion)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !isLicenseFile(e.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return "", "", err
+		}
+		return e.Name(), string(data), nil
+	}
+
+	return "", "", fmt.Errorf("%s@%s: no license file", modulePath, modVersion)
+}
+
+func (r *Resolver) fromProxy(ctx context.Context, modulePath, modVersion string) (string, string, error) {
+	proxy := r.Proxy
+	if proxy == nil {
+		proxy = version.NewProxyClient()
+	}
+
+	// module zips may be large, so they are spooled to disk
+	f, err := os.CreateTemp("", "license-*.zip")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := proxy.Zip(ctx, modulePath, modVersion, f); err != nil {
+		return "", "", err
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", "", err
+	}
+
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return "", "", err
+	}
+
+	// files in a module zip are named "path@version/file"
+	prefix := modulePath + "@" + modVersion + "/"
+	for _, f := range zr.File {
+		name := strings.TrimPrefix(f.Name, prefix)
+		if name == f.Name || strings.Contains(name, "/") || !isLicenseFile(name) {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return "", "", err
+		}
+		text, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return "", "", err
+		}
+		return name, string(text), nil
+	}
+
+	return "", "", fmt.Errorf("%s@%s: no license file", modulePath, modVersion)
+}
+
+// isLicenseFile reports whether name looks like the license file at the
+// root of a module, e.g. LICENSE, LICENSE.md or COPYING.
+func isLicenseFile(name string) bool {
+	base := strings.ToUpper(strings.TrimSuffix(name, path.Ext(name)))
+	switch base {
+	case "LICENSE", "LICENCE", "COPYING", "LICENSE-MIT", "LICENSE-APACHE", "UNLICENSE":
+		return true
+	}
+	return false
+}
+
+func modCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		gopath = filepath.Join(home, "go")
+	}
+
+	// only the first entry of a GOPATH list holds the module cache
+	if i := strings.IndexByte(gopath, os.PathListSeparator); i >= 0 {
+		gopath = gopath[:i]
+	}
+
+	return filepath.Join(gopath, "pkg", "mod")
+}
@@ -0,0 +1,98 @@
+package license
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const separator = "================================================================"
+
+// Summary counts the modules of the report by license.
+func (r *Report) Summary() map[string]int {
+	summary := make(map[string]int)
+	for _, m := range r.all() {
+		summary[m.License]++
+	}
+	return summary
+}
+
+// Unidentified returns the modules whose license could not be classified or
+// found, which need a closer look by a human.
+func (r *Report) Unidentified() []*Module {
+	var list []*Module
+	for _, m := range r.all() {
+		if m.License == Unknown {
+			list = append(list, m)
+		}
+	}
+	return list
+}
+
+// WriteNotice writes a NOTICE-style report: an overview of all modules and
+// their licenses, followed by the full license text of each module.
+func (r *Report) WriteNotice(w io.Writer) error {
+	ew := &errWriter{w: w}
+
+	title := "THIRD-PARTY NOTICES"
+	if r.File != "" {
+		title += " for " + r.File
+	}
+	fmt.Fprintf(ew, "%s\n\n", title)
+
+	tw := tabwriter.NewWriter(ew, 0, 4, 2, ' ', 0)
+	for _, m := range r.all() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", m.Path, m.Version, m.License)
+	}
+	tw.Flush()
+
+	summary := r.Summary()
+	ids := make([]string, 0, len(summary))
+	for id := range summary {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	fmt.Fprintf(ew, "\nLicenses:\n")
+	for _, id := range ids {
+		fmt.Fprintf(ew, "  %s: %d\n", id, summary[id])
+	}
+
+	for _, m := range r.all() {
+		fmt.Fprintf(ew, "\n%s\n%s %s\nLicense: %s\n", separator, m.Path, m.Version, m.License)
+		if m.Text == "" {
+			if m.Err != nil {
+				fmt.Fprintf(ew, "\n(license text not found: %v)\n", m.Err)
+			}
+			continue
+		}
+		fmt.Fprintf(ew, "File: %s\n\n%s\n", m.File, strings.TrimRight(m.Text, "\n"))
+	}
+
+	return ew.err
+}
+
+func (r *Report) all() []*Module {
+	if r.Main == nil {
+		return r.Modules
+	}
+	return append([]*Module{r.Main}, r.Modules...)
+}
+
+// errWriter remembers the first write error, so that WriteNotice can report
+// it without checking every single write.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.w.Write(p)
+	e.err = err
+	return n, err
+}
@@ -0,0 +1,60 @@
+package license
+
+import (
+	"strings"
+)
+
+// signature identifies a license by phrases that all appear in its text.
+type signature struct {
+	id      string
+	phrases []string
+}
+
+// signatures are checked in order, so that more specific licenses come
+// before those whose phrases they share (LGPL before GPL, BSD-3-Clause
+// before BSD-2-Clause, ...).
+var signatures = []signature{
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	// the GNU licenses mention each other, so match on their titles
+	{"AGPL-3.0", []string{"gnu affero general public license version 3"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license version 2.1"}},
+	{"LGPL-2.0", []string{"gnu library general public license version 2"}},
+	{"GPL-3.0", []string{"gnu general public license version 3"}},
+	{"GPL-2.0", []string{"gnu general public license version 2"}},
+	{"EPL-2.0", []string{"eclipse public license", "2.0"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"CC0-1.0", []string{"cc0 1.0 universal"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose with or without fee"}},
+	{"ISC", []string{"permission to use, copy, modify, and distribute this software for any purpose with or without fee"}},
+	{"BSL-1.0", []string{"boost software license"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "names of its contributors may be used"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+}
+
+// Identify returns the SPDX identifier of the license in text, or Unknown.
+func Identify(text string) string {
+	// normalize case and whitespace, since license files are wrapped and
+	// capitalized in all kinds of ways
+	norm := strings.ToLower(strings.Join(strings.Fields(text), " "))
+
+	for _, sig := range signatures {
+		if containsAll(norm, sig.phrases) {
+			return sig.id
+		}
+	}
+
+	return Unknown
+}
+
+func containsAll(s string, phrases []string) bool {
+	for _, p := range phrases {
+		if !strings.Contains(s, p) {
+			return false
+		}
+	}
+	return true
+}
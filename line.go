@@ -0,0 +1,151 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LineFormatTag is the first field of the line format, see FormatLine.
+const LineFormatTag = "gv1"
+
+// LineFields are the names of the fields of the line format, after the
+// tag, in order.
+var LineFields = []string{"app", "version", "go", "platform", "revision", "dirty", "lastCommit", "module"}
+
+// FormatLine formats d as a single line of fields separated by '|', for
+// log scrapers that can't handle JSON:
+//
+//	gv1|app|v1.2.3|go1.22.1|linux/amd64|4f3c2a1b9e0d|0|2024-03-01T12:00:00Z|github.com/you/app
+//
+// The fields are LineFormatTag followed by LineFields: the application
+// name, the version, the Go version, the platform, the revision, 1 if
+// built from a modified working tree or else 0, the time of the last
+// commit in RFC 3339 in UTC, and the module path. Unknown values are
+// empty.
+//
+// The format is stable: fields are never removed, reordered or changed in
+// meaning; new fields are only appended, so parsers must ignore extra
+// fields. A backslash escapes '|' as "\|", itself as "\\", and newlines,
+// carriage returns and tabs as "\n", "\r" and "\t"; other control
+// characters become "\xHH". The line has no trailing newline.
+func FormatLine(d Detail) string {
+	dirty := "0"
+	if d.IsDirty {
+		dirty = "1"
+	}
+
+	revision := d.Revision
+	if revision == "unknown" {
+		revision = ""
+	}
+
+	lastCommit := ""
+	if !d.LastCommit.IsZero() {
+		_, lastCommit = clockFormats(d.LastCommit)
+	}
+
+	fields := []string{
+		LineFormatTag, d.AppName, d.AppVersion, d.GoVersion, d.Platform,
+		revision, dirty, lastCommit, d.ModulePath,
+	}
+	for i, f := range fields {
+		fields[i] = escapeLineField(f)
+	}
+
+	return strings.Join(fields, "|")
+}
+
+func escapeLineField(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '|', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\x%02x`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// ParseLine splits a line made by FormatLine into its unescaped fields,
+// keyed by the names in LineFields. Fields appended by later versions of
+// the format are ignored.
+func ParseLine(line string) (map[string]string, error) {
+	var fields []string
+	var b strings.Builder
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == '|' {
+			fields = append(fields, b.String())
+			b.Reset()
+			continue
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+
+		if i++; i == len(line) {
+			return nil, errors.New("line ends with an unfinished escape")
+		}
+		switch e := line[i]; e {
+		case '|', '\\':
+			b.WriteByte(e)
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'x':
+			if i+2 >= len(line) {
+				return nil, errors.New("line ends with an unfinished escape")
+			}
+			v, err := strconv.ParseUint(line[i+1:i+3], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid escape \\x%s", line[i+1:i+3])
+			}
+			b.WriteByte(byte(v))
+			i += 2
+		default:
+			return nil, fmt.Errorf("invalid escape \\%c", e)
+		}
+	}
+	fields = append(fields, b.String())
+
+	if fields[0] != LineFormatTag {
+		return nil, fmt.Errorf("not a %s line", LineFormatTag)
+	}
+	if len(fields) < len(LineFields)+1 {
+		return nil, fmt.Errorf("line has %d fields, want at least %d", len(fields), len(LineFields)+1)
+	}
+
+	m := make(map[string]string, len(LineFields))
+	for i, name := range LineFields {
+		m[name] = fields[i+1]
+	}
+
+	return m, nil
+}
+
+func renderLine(d Detail, w io.Writer) error {
+	_, err := fmt.Fprintln(w, FormatLine(d))
+	return err
+}
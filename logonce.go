@@ -0,0 +1,71 @@
+package version
+
+import (
+	"log"
+	"sync"
+)
+
+// logOnce remembers which loggers have logged the version of which
+// application, see LogOnce.
+var logOnce = struct {
+	sync.Mutex
+	done map[logKey]bool
+}{done: make(map[logKey]bool)}
+
+type logKey struct {
+	logger interface{}
+	app    string
+}
+
+// LogOnce logs the version of the running binary to logger, unless it has
+// already been logged there. Libraries embedding this package can call it
+// at init, and the version is logged only once no matter how many of them
+// share the logger. A nil logger means the standard logger of package log.
+// It reports whether the version was logged.
+func LogOnce(logger *log.Logger) bool {
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	d, ok := markLogged(logger)
+	if !ok {
+		return false
+	}
+
+	logger.Print(logLine(d))
+
+	return true
+}
+
+// markLogged returns the detail of the running binary, unless it has
+// already been logged to logger.
+func markLogged(logger interface{}) (*Detail, bool) {
+	d, err := GetDetail()
+	if err != nil {
+		return nil, false
+	}
+
+	key := logKey{logger: logger, app: d.AppName}
+
+	logOnce.Lock()
+	defer logOnce.Unlock()
+
+	if logOnce.done[key] {
+		return nil, false
+	}
+	logOnce.done[key] = true
+
+	return d, true
+}
+
+func logLine(d *Detail) string {
+	line := d.AppName + " version " + d.AppVersion + ", built with " + d.GoVersion
+	if d.Revision != "" && d.Revision != "unknown" {
+		line += ", revision " + d.Revision
+		if d.IsDirty {
+			line += " (dirty)"
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,35 @@
+//go:build go1.21
+// +build go1.21
+
+package version
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogOnceSlog is LogOnce for structured loggers: it logs the version of the
+// running binary to logger at info level, with the build information as
+// attributes, unless it has already been logged there. A nil logger means
+// slog.Default().
+func LogOnceSlog(ctx context.Context, logger *slog.Logger) bool {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	d, ok := markLogged(logger)
+	if !ok {
+		return false
+	}
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "build info",
+		slog.String("app", d.AppName),
+		slog.String("version", d.AppVersion),
+		slog.String("goVersion", d.GoVersion),
+		slog.String("revision", d.Revision),
+		slog.Bool("dirty", d.IsDirty),
+		slog.String("platform", d.Platform),
+	)
+
+	return true
+}
@@ -0,0 +1,181 @@
+package version
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Artifact is a release binary in a ReleaseMatrix.
+type Artifact struct {
+	File     string `json:"file"`     // relative to the release directory
+	Platform string `json:"platform"` // GOOS/GOARCH, e.g. linux/arm64
+	Version  string `json:"version"`
+	Commit   string `json:"commit,omitempty"`
+	Dirty    bool   `json:"dirty,omitempty"`
+	SHA256   string `json:"sha256"`
+}
+
+// ReleaseMatrix is the platform matrix of a release, made by
+// NewReleaseMatrix for the release checklist. It's marshalled to JSON as
+// is, and rendered as Markdown by Render.
+type ReleaseMatrix struct {
+	Version   string     `json:"version"` // of the majority of the artifacts
+	Commit    string     `json:"commit,omitempty"`
+	Artifacts []Artifact `json:"artifacts"`
+	Problems  []string   `json:"problems,omitempty"` // why the release must not be signed off
+}
+
+// NewReleaseMatrix inspects the Go binaries in dir, typically the output
+// of a cross-compiling release build with one binary per platform, and
+// checks that they are all built from the same version and commit, from a
+// clean working tree, with one binary per platform. Archives are not
+// unpacked, run it on the binaries before packaging.
+func NewReleaseMatrix(dir string) (*ReleaseMatrix, error) {
+	reports, err := ScanDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, fmt.Errorf("no Go binaries found in %s", dir)
+	}
+
+	m := &ReleaseMatrix{}
+	versions := map[string]int{}
+	commits := map[string]int{}
+	platforms := map[string][]string{}
+
+	for _, r := range reports {
+		a, err := newArtifact(dir, r)
+		if err != nil {
+			return nil, err
+		}
+
+		m.Artifacts = append(m.Artifacts, a)
+		versions[a.Version]++
+		commits[a.Commit]++
+		platforms[a.Platform] = append(platforms[a.Platform], a.File)
+	}
+
+	sort.Slice(m.Artifacts, func(i, j int) bool {
+		a, b := m.Artifacts[i], m.Artifacts[j]
+		if a.Platform != b.Platform {
+			return a.Platform < b.Platform
+		}
+		return a.File < b.File
+	})
+
+	m.Version = majority(versions)
+	m.Commit = majority(commits)
+
+	for _, a := range m.Artifacts {
+		switch {
+		case a.Version != m.Version:
+			m.problem("%s has version %s instead of %s", a.File, a.Version, m.Version)
+		case a.Commit != m.Commit:
+			m.problem("%s is built from commit %s instead of %s", a.File, a.Commit, m.Commit)
+		}
+		if a.Dirty {
+			m.problem("%s is built from a modified working tree", a.File)
+		}
+	}
+
+	for _, a := range m.Artifacts {
+		if files := platforms[a.Platform]; len(files) > 1 && files[0] == a.File {
+			m.problem("%s has %d binaries: %s", a.Platform, len(files), strings.Join(files, ", "))
+		}
+	}
+
+	return m, nil
+}
+
+func newArtifact(dir string, r *BinaryReport) (Artifact, error) {
+	a := Artifact{File: r.File, Version: r.Info.Main.Version}
+	if rel, err := filepath.Rel(dir, r.File); err == nil {
+		a.File = filepath.ToSlash(rel)
+	}
+
+	var goos, goarch string
+	for _, s := range r.Info.Settings {
+		switch s.Key {
+		case "GOOS":
+			goos = s.Value
+		case "GOARCH":
+			goarch = s.Value
+		case "vcs.revision":
+			a.Commit = s.Value
+		case "vcs.modified":
+			a.Dirty = s.Value == "true"
+		}
+	}
+	a.Platform = goos + "/" + goarch
+
+	f, err := os.Open(r.File)
+	if err != nil {
+		return a, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return a, err
+	}
+	a.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	return a, nil
+}
+
+// majority returns the most frequent key of counts, the least one of equally
+// frequent keys.
+func majority(counts map[string]int) string {
+	best := ""
+	for k, n := range counts {
+		if n > counts[best] || n == counts[best] && k < best {
+			best = k
+		}
+	}
+	return best
+}
+
+func (m *ReleaseMatrix) problem(format string, args ...interface{}) {
+	m.Problems = append(m.Problems, fmt.Sprintf(format, args...))
+}
+
+// OK reports whether the release can be signed off.
+func (m *ReleaseMatrix) OK() bool {
+	return len(m.Problems) == 0
+}
+
+// Render writes the matrix as a Markdown table followed by the sign-off
+// status, for the release checklist.
+func (m *ReleaseMatrix) Render(w io.Writer) error {
+	ew := &errWriter{w: w}
+
+	fmt.Fprintf(ew, "| Platform | File | Version | Commit | SHA-256 |\n")
+	fmt.Fprintf(ew, "|---|---|---|---|---|\n")
+	for _, a := range m.Artifacts {
+		commit := a.Commit
+		if a.Dirty {
+			commit += " (dirty)"
+		}
+		fmt.Fprintf(ew, "| %s | `%s` | %s | %s | `%s` |\n",
+			a.Platform, a.File, a.Version, commit, a.SHA256)
+	}
+
+	fmt.Fprintln(ew)
+	if m.OK() {
+		fmt.Fprintf(ew, "- [x] All %d binaries embed %s at commit %s.\n",
+			len(m.Artifacts), m.Version, m.Commit)
+	} else {
+		for _, p := range m.Problems {
+			fmt.Fprintf(ew, "- [ ] %s\n", p)
+		}
+	}
+
+	return ew.err
+}
@@ -0,0 +1,132 @@
+package version
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// metrics of the update subsystem, see WriteMetrics.
+var metrics struct {
+	checks   int64 // atomic
+	failures int64 // atomic
+
+	mu        sync.Mutex
+	module    string
+	current   string
+	latest    string
+	available bool
+	lastCheck time.Time
+}
+
+// recordUpdate updates the metrics with the outcome of an update check.
+func recordUpdate(u *UpdateInfo, err error) {
+	atomic.AddInt64(&metrics.checks, 1)
+	if err != nil {
+		atomic.AddInt64(&metrics.failures, 1)
+		return
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	metrics.module = u.Module
+	metrics.current = u.Current
+	metrics.latest = ""
+	if u.Latest != nil {
+		metrics.latest = u.Latest.Version
+	}
+	metrics.available = u.Available
+	metrics.lastCheck = time.Now()
+}
+
+// WriteMetrics writes the metrics of the update subsystem to w in the
+// Prometheus text exposition format, so that fleet-wide dashboards can
+// show upgrade lag without scraping logs:
+//
+//	go_version_update_checks_total             update checks performed
+//	go_version_update_check_failures_total     update checks that failed
+//	go_version_update_available                1 if a newer version exists
+//	go_version_update_last_check_timestamp_seconds
+//	go_version_update_latest_info{module,current,latest}
+//	go_version_build_timestamp_seconds         time of the commit of the build
+//	go_version_build_age_seconds               seconds since the commit of the build
+//
+// The update gauges are only written after a successful check, and the
+// build gauges only for builds carrying their commit time, see
+// Detail.Age. The build age lets platform teams alert on production
+// running builds older than policy allows:
+//
+//	go_version_build_age_seconds > 90 * 86400
+//
+// Add it to an existing /metrics endpoint, or serve it with
+// MetricsHandler.
+func WriteMetrics(w io.Writer) error {
+	var b bytes.Buffer
+
+	writeMetric(&b, "go_version_update_checks_total", "counter",
+		"Number of update checks performed.", "", atomic.LoadInt64(&metrics.checks))
+	writeMetric(&b, "go_version_update_check_failures_total", "counter",
+		"Number of update checks that failed.", "", atomic.LoadInt64(&metrics.failures))
+
+	metrics.mu.Lock()
+	if !metrics.lastCheck.IsZero() {
+		available := int64(0)
+		if metrics.available {
+			available = 1
+		}
+		writeMetric(&b, "go_version_update_available", "gauge",
+			"Whether a newer version is available, as of the last check.", "", available)
+		writeMetric(&b, "go_version_update_last_check_timestamp_seconds", "gauge",
+			"Time of the last successful update check.", "", metrics.lastCheck.Unix())
+		writeMetric(&b, "go_version_update_latest_info", "gauge",
+			"The current and the latest version, as of the last check.",
+			labels("module", metrics.module, "current", metrics.current, "latest", metrics.latest), 1)
+	}
+	metrics.mu.Unlock()
+
+	if d, err := GetDetail(); err == nil {
+		if age, ok := d.Age(); ok {
+			commit := d.LastCommit
+			if commit.IsZero() {
+				commit = d.ModVersion.Time
+			}
+			writeMetric(&b, "go_version_build_timestamp_seconds", "gauge",
+				"Time of the commit the running binary is built from.", "", commit.Unix())
+			writeMetric(&b, "go_version_build_age_seconds", "gauge",
+				"Seconds since the commit the running binary is built from.", "", int64(age/time.Second))
+		}
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// MetricsHandler returns an http.Handler serving WriteMetrics.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteMetrics(w)
+	})
+}
+
+func writeMetric(b *bytes.Buffer, name, typ, help, labels string, value int64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n%s%s %d\n", name, help, name, typ, name, labels, value)
+}
+
+// labels formats name/value pairs as a Prometheus label set.
+func labels(pairs ...string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	var parts []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+escape.Replace(pairs[i+1])+`"`)
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,60 @@
+package version
+
+// MigrationReport lists the registered schemas which changed between two
+// binary versions.
+type MigrationReport struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Changed []Schema `json:"changed,omitempty"` // introduced after From, up to To
+	Unknown []Schema `json:"unknown,omitempty"` // can't tell, see NeedsMigration
+}
+
+// Needed reports whether any schema changed or might have changed.
+func (r *MigrationReport) Needed() bool {
+	return len(r.Changed) > 0 || len(r.Unknown) > 0
+}
+
+// NeedsMigration reports which schemas registered by RegisterSchema changed
+// between the binary versions from and to, i.e. were introduced by a version
+// later than from and not later than to. A schema without the Since version,
+// or versions which aren't valid semver, can't be judged and are listed as
+// unknown, except that nothing changes when from and to are equal.
+func NeedsMigration(from, to string) *MigrationReport {
+	r := &MigrationReport{From: from, To: to}
+	if from == to {
+		return r
+	}
+
+	fv, errFrom := Parse(from)
+	tv, errTo := Parse(to)
+
+	for _, s := range Schemas() {
+		sv, err := Parse(s.Since)
+		switch {
+		case err != nil || errFrom != nil || errTo != nil:
+			r.Unknown = append(r.Unknown, s)
+		case sv.Compare(fv) > 0 && sv.Compare(tv) <= 0:
+			r.Changed = append(r.Changed, s)
+		}
+	}
+
+	return r
+}
+
+// CheckMigration compares the registered schemas of the running binary with
+// the last-run version persisted by RecordRun or CheckDowngrade, so it must
+// be called before those record the running version. It returns nil if there
+// is no last run, e.g. on a fresh install.
+func CheckMigration() (*MigrationReport, error) {
+	last, err := ReadLastRun()
+	if err != nil || last == nil {
+		return nil, err
+	}
+
+	cur, err := currentRun()
+	if err != nil {
+		return nil, err
+	}
+
+	return NeedsMigration(last.Version, cur.Version), nil
+}
@@ -0,0 +1,91 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NightlyScheme describes how untagged nightly/dev builds are named, e.g.
+// v1.5.0-dev.20240605+abc1234.
+//
+// A nightly version is derived from the last release: the minor version is
+// bumped and the pre-release is the label followed by the build date, so
+// nightly builds sort after the last release and before the next one. After
+// a pre-release such as v1.5.0-rc.1, the label and date are appended to it
+// instead, giving v1.5.0-rc.1.dev.20240605.
+type NightlyScheme struct {
+	Label      string // pre-release label, e.g. "dev" or "nightly"
+	DateLayout string // layout of the date part, must be all digits, e.g. "20060102"
+	CommitLen  int    // length of the abbreviated commit id in build metadata
+}
+
+// NightlyNaming is the scheme used by GetAppVersion to recognize nightly
+// builds. Assign it during initialization to use another naming convention.
+var NightlyNaming = NightlyScheme{
+	Label:      "dev",
+	DateLayout: "20060102",
+	CommitLen:  7,
+}
+
+// Format synthesizes a nightly version from the last release, the time of
+// the build and the commit id. commit may be empty.
+func (s NightlyScheme) Format(lastRelease string, t time.Time, commit string) (string, error) {
+	last, err := Parse(lastRelease)
+	if err != nil {
+		return "", err
+	}
+
+	next := *last
+	next.Build = ""
+
+	date := s.Label + "." + t.UTC().Format(s.DateLayout)
+	if next.Prerelease != "" {
+		next.Prerelease += "." + date
+	} else {
+		next.Minor++
+		next.Patch = 0
+		next.Prerelease = date
+	}
+
+	if commit != "" {
+		if s.CommitLen > 0 && len(commit) > s.CommitLen {
+			commit = commit[:s.CommitLen]
+		}
+		next.Build = commit
+	}
+
+	v := next.String()
+	if _, err := Parse(v); err != nil {
+		return "", fmt.Errorf("nightly scheme produces %w", err)
+	}
+
+	return v, nil
+}
+
+// Parse recognizes a nightly version produced by Format. It returns the
+// version with the nightly parts removed (the version under development, or
+// the pre-release it follows), the build date and the commit id.
+func (s NightlyScheme) Parse(v string) (next string, t time.Time, commit string, ok bool) {
+	ver, err := Parse(v)
+	if err != nil {
+		return "", time.Time{}, "", false
+	}
+
+	ids := strings.Split(ver.Prerelease, ".")
+	n := len(ids)
+	if n < 2 || ids[n-2] != s.Label {
+		return "", time.Time{}, "", false
+	}
+
+	t, err = time.Parse(s.DateLayout, ids[n-1])
+	if err != nil {
+		return "", time.Time{}, "", false
+	}
+
+	commit = ver.Build
+	ver.Build = ""
+	ver.Prerelease = strings.Join(ids[:n-2], ".")
+
+	return ver.String(), t, commit, true
+}
@@ -0,0 +1,65 @@
+package version
+
+import (
+	"io"
+	"sync"
+	"text/template"
+)
+
+var (
+	noticesMu sync.RWMutex
+	notices   string
+)
+
+// SetNotices sets the third-party notices shipped inside the binary, such
+// as a NOTICES file generated by license.Report.WriteNotice at release time
+// and embedded at build time, so that legal attribution travels with the
+// binary:
+//
+//	//go:embed NOTICES
+//	var notices string
+//
+//	func init() {
+//		version.SetNotices(notices)
+//	}
+//
+// They are printed by PrintVersionWith if Options.Notices is set, e.g. for
+// `app --version --notices`.
+func SetNotices(text string) {
+	noticesMu.Lock()
+	defer noticesMu.Unlock()
+
+	notices = text
+}
+
+// Notices returns the third-party notices set by SetNotices.
+func Notices() string {
+	noticesMu.RLock()
+	defer noticesMu.RUnlock()
+
+	return notices
+}
+
+// Notices returns the third-party notices set by SetNotices, so that detail
+// templates can use {{.Notices}}.
+func (d Detail) Notices() string {
+	return Notices()
+}
+
+// DefaultNotices is the template printed after the version by
+// PrintVersionWith if Options.Notices is set.
+const DefaultNotices = `
+{{if .Notices}}Third-party notices:
+
+{{.Notices}}{{else}}No third-party notices are included in {{.AppName}}.
+{{end}}`
+
+// renderNotices executes DefaultNotices with d.
+func renderNotices(d Detail, w io.Writer) error {
+	tmpl, err := template.New("notices").Parse(DefaultNotices)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(w, d)
+}
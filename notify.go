@@ -0,0 +1,262 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of notifications.
+const (
+	NotifyUpdateAvailable = "update-available" // a newer version has been published
+	NotifyVersionSkew     = "version-skew"     // peers run different versions
+	NotifyVersionChanged  = "version-changed"  // a host runs a new version
+)
+
+// Notification is an event operators should learn about.
+type Notification struct {
+	Kind    string            `json:"kind"`
+	Time    time.Time         `json:"time"`
+	App     string            `json:"app"`
+	Title   string            `json:"title"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// Notifier delivers notifications somewhere operators actually look.
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// NotifierFunc is an adapter to allow the use of ordinary functions as
+// Notifiers.
+type NotifierFunc func(ctx context.Context, n *Notification) error
+
+// Notify implements Notifier.
+func (f NotifierFunc) Notify(ctx context.Context, n *Notification) error {
+	return f(ctx, n)
+}
+
+// UpdateNotification returns the NotifyUpdateAvailable notification for u,
+// or nil if no update is available.
+func UpdateNotification(u *UpdateInfo) *Notification {
+	if u == nil || !u.Available {
+		return nil
+	}
+
+	app := u.Module
+	if d, err := GetDetail(); err == nil {
+		app = d.AppName
+	}
+
+	return &Notification{
+		Kind:    NotifyUpdateAvailable,
+		Time:    time.Now(),
+		App:     app,
+		Title:   app + " " + u.Latest.Version + " is available",
+		Message: fmt.Sprintf("%s %s is available, you are running %s.", app, u.Latest.Version, u.Current),
+		Fields: map[string]string{
+			"module":  u.Module,
+			"current": u.Current,
+			"latest":  u.Latest.Version,
+		},
+	}
+}
+
+// WriterNotifier returns a Notifier writing each notification as a single
+// line to w, typically os.Stderr.
+func WriterNotifier(w io.Writer) Notifier {
+	return NotifierFunc(func(ctx context.Context, n *Notification) error {
+		_, err := fmt.Fprintf(w, "%s: %s\n", n.App, n.Message)
+		return err
+	})
+}
+
+// WebhookNotifier POSTs each notification as JSON to URL. It honors the
+// offline mode and RemoteOptions like every other remote call.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client // default RemoteOptions.Client
+
+	// Format encodes the body of the request, default the Notification
+	// itself. SlackFormat and TeamsFormat suit the incoming webhooks of
+	// Slack and Microsoft Teams.
+	Format func(n *Notification) ([]byte, error)
+}
+
+// Notify implements Notifier.
+func (wh *WebhookNotifier) Notify(ctx context.Context, n *Notification) error {
+	format := wh.Format
+	if format == nil {
+		format = func(n *Notification) ([]byte, error) { return json.Marshal(n) }
+	}
+
+	body, err := format(n)
+	if err != nil {
+		return err
+	}
+
+	_, err = post(ctx, wh.Client, wh.URL, "application/json", body)
+	return err
+}
+
+// SlackFormat encodes a notification for a Slack incoming webhook, as a
+// message with the title in bold and the fields as a list.
+func SlackFormat(n *Notification) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*\n%s", n.Title, n.Message)
+	for _, k := range sortedKeys(n.Fields) {
+		fmt.Fprintf(&b, "\n• %s: `%s`", k, n.Fields[k])
+	}
+
+	return json.Marshal(map[string]string{"text": b.String()})
+}
+
+// TeamsFormat encodes a notification for a Microsoft Teams incoming
+// webhook, as a message card with the fields as facts.
+func TeamsFormat(n *Notification) ([]byte, error) {
+	type fact struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	facts := []fact{}
+	for _, k := range sortedKeys(n.Fields) {
+		facts = append(facts, fact{k, n.Fields[k]})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"@type":    "MessageCard",
+		"@context": "https://schema.org/extensions",
+		"summary":  n.Title,
+		"title":    n.Title,
+		"text":     n.Message,
+		"sections": []interface{}{map[string]interface{}{"facts": facts}},
+	})
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// DesktopNotifier shows notifications on the desktop, via osascript on macOS
+// and notify-send on Linux and BSDs.
+type DesktopNotifier struct{}
+
+// Notify implements Notifier.
+func (DesktopNotifier) Notify(ctx context.Context, n *Notification) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace
+		script := fmt.Sprintf(`display notification "%s" with title "%s"`, quote(n.Message), quote(n.Title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name="+n.App, n.Title, n.Message)
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+
+	return cmd.Run()
+}
+
+// MultiNotifier returns a Notifier delivering to all notifiers, and
+// returning the first error after trying all of them.
+func MultiNotifier(notifiers ...Notifier) Notifier {
+	return NotifierFunc(func(ctx context.Context, n *Notification) error {
+		var first error
+		for _, notifier := range notifiers {
+			if err := notifier.Notify(ctx, n); err != nil && first == nil {
+				first = err
+			}
+		}
+		return first
+	})
+}
+
+// NotifyUpdate checks for an update with checker and, if one is available,
+// sends the UpdateNotification to notifier. It returns the result of the
+// check.
+func NotifyUpdate(ctx context.Context, checker UpdateChecker, notifier Notifier) (*UpdateInfo, error) {
+	u, err := CheckUpdateWith(ctx, checker)
+	if err != nil {
+		return nil, err
+	}
+
+	if n := UpdateNotification(u); n != nil {
+		if err := notifier.Notify(ctx, n); err != nil {
+			return u, err
+		}
+	}
+
+	return u, nil
+}
+
+// AnnounceVersionChange compares the running version to the last-run
+// version persisted in the state directory, and if they differ sends a
+// NotifyVersionChanged notification to notifier, e.g. a WebhookNotifier,
+// reading "version changed on host X: old → new". Call it on startup
+// instead of RecordRun. It returns the notification sent, nil if the
+// version didn't change or if this is the first run.
+//
+// The running version is recorded as the last-run version once the
+// notification is delivered, so that a failed delivery is retried on the
+// next start.
+func AnnounceVersionChange(ctx context.Context, notifier Notifier) (*Notification, error) {
+	last, err := ReadLastRun()
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := currentRun()
+	if err != nil {
+		return nil, err
+	}
+
+	if last == nil || last.Version == cur.Version {
+		return nil, writeState(lastRunFile, cur)
+	}
+
+	d, err := GetDetail()
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+
+	n := &Notification{
+		Kind:    NotifyVersionChanged,
+		Time:    cur.Time,
+		App:     d.AppName,
+		Title:   fmt.Sprintf("%s version changed on %s", d.AppName, hostname),
+		Message: fmt.Sprintf("%s version changed on %s: %s → %s", d.AppName, hostname, last.Version, cur.Version),
+		Fields: map[string]string{
+			"host":     hostname,
+			"previous": last.Version,
+			"current":  cur.Version,
+		},
+	}
+	if cur.Revision != "" {
+		n.Fields["revision"] = cur.Revision
+	}
+
+	if err := notifier.Notify(ctx, n); err != nil {
+		return n, err
+	}
+
+	return n, writeState(lastRunFile, cur)
+}
@@ -0,0 +1,52 @@
+//go:build go1.21
+// +build go1.21
+
+package version
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogNotifier returns a Notifier logging each notification to logger at
+// warning level, with the notification fields as attributes.
+func SlogNotifier(logger *slog.Logger) Notifier {
+	return NotifierFunc(func(ctx context.Context, n *Notification) error {
+		attrs := []slog.Attr{
+			slog.String("kind", n.Kind),
+			slog.String("app", n.App),
+		}
+		for k, v := range n.Fields {
+			attrs = append(attrs, slog.String(k, v))
+		}
+		logger.LogAttrs(ctx, slog.LevelWarn, n.Message, attrs...)
+		return nil
+	})
+}
+
+// SlogUpdateObserver returns an observer for SetUpdateObserver logging each
+// update check to logger: outdated builds at warning level, failed checks
+// at error level and the others at info level.
+func SlogUpdateObserver(logger *slog.Logger) func(ctx context.Context, r *UpdateResult) {
+	return func(ctx context.Context, r *UpdateResult) {
+		attrs := []slog.Attr{
+			slog.String("module", r.Module),
+			slog.String("current", r.Current),
+			slog.String("latest", r.Latest),
+			slog.Bool("available", r.Available),
+			slog.Duration("age", r.Age),
+			slog.String("channel", r.Channel),
+		}
+
+		level, msg := slog.LevelInfo, "update check"
+		switch {
+		case r.Err != nil:
+			level, msg = slog.LevelError, "update check failed"
+			attrs = append(attrs, slog.String("error", r.Err.Error()))
+		case r.Available:
+			level, msg = slog.LevelWarn, "update available"
+		}
+
+		logger.LogAttrs(ctx, level, msg, attrs...)
+	}
+}
@@ -0,0 +1,61 @@
+package version
+
+// SemverCorpus returns a corpus of versions in ascending precedence, for
+// reviewing the soundness of version orderings: the versions of a group
+// have equal precedence, as they only differ by build metadata, and each
+// group is lower than the next. It covers the precedence examples of the
+// semver specification, numeric and alphanumeric identifiers, identifiers
+// of different lengths, and numbers too large for 32 bits.
+//
+// The tests of this package check Compare against it; other
+// implementations, such as Compare of golang.org/x/mod/semver, can be
+// checked the same way. The corpus is a copy the caller may modify.
+func SemverCorpus() [][]string {
+	corpus := make([][]string, len(semverCorpus))
+	for i, g := range semverCorpus {
+		corpus[i] = append([]string(nil), g...)
+	}
+
+	return corpus
+}
+
+var semverCorpus = [][]string{
+	{"v0.0.0-0"},
+	{"v0.0.0"},
+	{"v0.0.1-0.20240101000000-abcdefabcdef"},
+	{"v0.0.1"},
+	{"v0.1.0"},
+	{"v0.9.0"},
+	{"v0.10.0"},
+	{"v1.0.0-0"},
+	{"v1.0.0-1"},
+	{"v1.0.0-2"},
+	{"v1.0.0-10"},
+	{"v1.0.0--"},
+	{"v1.0.0-0A"},
+	{"v1.0.0-A"},
+	{"v1.0.0-a"},
+	{"v1.0.0-alpha", "v1.0.0-alpha+001"},
+	{"v1.0.0-alpha.1"},
+	{"v1.0.0-alpha.1.1"},
+	{"v1.0.0-alpha.beta"},
+	{"v1.0.0-beta"},
+	{"v1.0.0-beta.2"},
+	{"v1.0.0-beta.11"},
+	{"v1.0.0-beta.11.0"},
+	{"v1.0.0-rc.1", "v1.0.0-rc.1+build.1", "v1.0.0-rc.1+build.2"},
+	{"v1.0.0-rc.1.0.20240101000000-abcdefabcdef"},
+	{"v1.0.0-rc.2"},
+	{"v1.0.0-rc-1"},
+	{"v1.0.0", "v1.0.0+20130313144700", "v1.0.0+exp.sha.5114f85", "v1.0.0+dirty"},
+	{"v1.0.1-0.20240101000000-abcdefabcdef"},
+	{"v1.0.1"},
+	{"v1.2.3"},
+	{"v1.2.10"},
+	{"v1.10.0"},
+	{"v2.0.0+incompatible"},
+	{"v2.0.1"},
+	{"v10.0.0"},
+	{"v4294967296.0.0"},
+	{"v18446744073709551615.0.0"},
+}
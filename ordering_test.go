@@ -0,0 +1,118 @@
+package version
+
+import (
+	"fmt"
+	"testing"
+
+	"golang.org/x/mod/semver"
+)
+
+func TestCompareCorpus(t *testing.T) {
+	corpus := SemverCorpus()
+	for i, gi := range corpus {
+		for j, gj := range corpus {
+			want := sign(i - j)
+			for _, v := range gi {
+				for _, w := range gj {
+					if got := sign(Compare(v, w)); got != want {
+						t.Errorf("Compare(%s, %s) = %d, want %d", v, w, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCompareOrdering(t *testing.T) {
+	// invalid versions must be ordered consistently too
+	versions := append(corpusVersions(), "", "v1", "1.2.3", "v1.2.3-", "v01.2.3", "garbage")
+	if err := checkOrdering(Compare, versions); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCompareConsistent(t *testing.T) {
+	versions := corpusVersions()
+	for _, a := range versions {
+		for _, b := range versions {
+			if got, want := sign(Compare(a, b)), sign(semver.Compare(a, b)); got != want {
+				t.Errorf("Compare(%s, %s) = %d, semver.Compare says %d", a, b, got, want)
+			}
+		}
+	}
+}
+
+// checkOrdering checks that cmp is a consistent ordering of versions: it
+// must be reflexive, antisymmetric and transitive, both for equality and
+// for precedence. It returns the first violation found.
+func checkOrdering(cmp func(v, w string) int, versions []string) error {
+	for _, a := range versions {
+		if c := cmp(a, a); c != 0 {
+			return fmt.Errorf("not reflexive: compare(%s, %s) = %d", a, a, c)
+		}
+	}
+
+	for _, a := range versions {
+		for _, b := range versions {
+			if ab, ba := sign(cmp(a, b)), sign(cmp(b, a)); ab != -ba {
+				return fmt.Errorf("not antisymmetric: compare(%s, %s) = %d, compare(%s, %s) = %d", a, b, ab, b, a, ba)
+			}
+		}
+	}
+
+	for _, a := range versions {
+		for _, b := range versions {
+			ab := sign(cmp(a, b))
+			if ab > 0 {
+				continue
+			}
+			for _, c := range versions {
+				bc := sign(cmp(b, c))
+				if bc > 0 {
+					continue
+				}
+				// a <= b <= c implies a <= c, and a < c if either is strict
+				want := ab + bc
+				if want < -1 {
+					want = -1
+				}
+				if ac := sign(cmp(a, c)); ac != want {
+					return fmt.Errorf("not transitive: %s %s %s %s %s, but %s %s %s",
+						a, relation(ab), b, relation(bc), c, a, relation(ac), c)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// corpusVersions returns the versions of the corpus, in ascending order.
+func corpusVersions() []string {
+	var versions []string
+	for _, g := range SemverCorpus() {
+		versions = append(versions, g...)
+	}
+
+	return versions
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func relation(c int) string {
+	switch c {
+	case -1:
+		return "<"
+	case 1:
+		return ">"
+	}
+	return "=="
+}
@@ -0,0 +1,183 @@
+package version
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Pin is the required version of a tool, read by ParsePins.
+type Pin struct {
+	Tool       string      // name of the tool, or the package path of its main package
+	Constraint *Constraint // the required version
+	Line       int         // 1-based line number in the pin file
+}
+
+// Pins is a list of pins in the order of the pin file.
+type Pins []Pin
+
+// ParsePins parses a pin file mapping tools to required versions, so that
+// tools bootstrapping development environments can check what's installed.
+// Two formats are accepted, and may even be mixed:
+//
+// The .tool-versions format of asdf and mise, one tool per line followed by
+// one or more versions, any of which is acceptable:
+//
+//	golangci-lint 1.55.2
+//	gofumpt 0.5.0 0.6.0
+//
+// A simple YAML mapping as in pins.yaml, with a constraint per tool, see
+// ParseConstraint:
+//
+//	golangci-lint: ">=1.55, <2"
+//	gofumpt: ^0.6
+//
+// Blank lines and comments starting with # are ignored, and so are the
+// entries of .tool-versions that aren't versions, such as system or ref:....
+func ParsePins(r io.Reader) (Pins, error) {
+	var pins Pins
+
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		tool, constraint, err := parsePinLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if constraint == "" {
+			continue
+		}
+
+		c, err := ParseConstraint(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		pins = append(pins, Pin{Tool: tool, Constraint: c, Line: n})
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return pins, nil
+}
+
+// parsePinLine splits a line of a pin file into the tool and its constraint.
+// The constraint of a .tool-versions line is empty if it has no versions
+// but only entries like system, latest, ref:... or path:....
+func parsePinLine(line string) (tool, constraint string, err error) {
+	fields := strings.Fields(line)
+
+	if strings.HasSuffix(fields[0], ":") {
+		tool, constraint, _ = strings.Cut(line, ":")
+		tool = strings.TrimSpace(tool)
+		constraint = strings.Trim(strings.TrimSpace(constraint), `"'`)
+		if constraint == "" {
+			return "", "", fmt.Errorf("no constraint given for %s", tool)
+		}
+		return tool, constraint, nil
+	}
+
+	if len(fields) < 2 {
+		return "", "", fmt.Errorf("no version given for %s", fields[0])
+	}
+
+	var versions []string
+	for _, v := range fields[1:] {
+		if _, err := ParseConstraint(v); err == nil {
+			versions = append(versions, v)
+		}
+	}
+
+	return fields[0], strings.Join(versions, " || "), nil
+}
+
+// ReadPins reads the pin file at file, see ParsePins.
+func ReadPins(file string) (Pins, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pins, err := ParsePins(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	return pins, nil
+}
+
+// Lookup returns the first pin of any of the given names of a tool.
+func (p Pins) Lookup(names ...string) (Pin, bool) {
+	for _, pin := range p {
+		for _, name := range names {
+			if name != "" && pin.Tool == name {
+				return pin, true
+			}
+		}
+	}
+
+	return Pin{}, false
+}
+
+// Check checks the running binary against its pin, which is looked up by
+// the application name and the package path of the main package. It
+// returns a *MismatchError if the binary doesn't satisfy the pin, and nil if
+// it does or isn't pinned.
+func (p Pins) Check() error {
+	info, ok := readBuildInfo()
+	if !ok {
+		return ErrNoBuildInfo
+	}
+
+	d := newDetail(info)
+
+	return p.check(info.Path, d.AppVersion, d.AppName, info.Path)
+}
+
+// CheckBinary is like Check for the Go binary at file, read by Inspect. The
+// pin is looked up by the file name, without .exe, the last element of the
+// package path of the main package, and the package path itself.
+func (p Pins) CheckBinary(file string) error {
+	report, err := Inspect(file)
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(file), ".exe")
+	pkg := report.Info.Path
+
+	return p.check(pkg, report.Info.Main.Version, name, path.Base(pkg), pkg)
+}
+
+func (p Pins) check(pkg, version string, names ...string) error {
+	pin, ok := p.Lookup(names...)
+	if !ok {
+		return nil
+	}
+
+	if v, err := Parse(version); err == nil && pin.Constraint.Check(v) {
+		return nil
+	}
+
+	return &MismatchError{
+		App:        names[0],
+		Path:       pkg,
+		Version:    version,
+		Constraint: pin.Constraint.String(),
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !js && !wasip1
+// +build !js,!wasip1
+
+package version
+
+import "runtime"
+
+// Platform describes where the binary is running, in the form GOOS/GOARCH.
+const Platform = runtime.GOOS + "/" + runtime.GOARCH
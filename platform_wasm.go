@@ -0,0 +1,8 @@
+//go:build js || wasip1
+// +build js wasip1
+
+package version
+
+// Platform is simply "wasm" for WebAssembly builds, because the real host
+// (a browser, node or some WASI runtime) can't be known at compile time.
+const Platform = "wasm"
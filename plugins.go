@@ -0,0 +1,91 @@
+package version
+
+import (
+	"sort"
+	"sync"
+)
+
+// Plugin describes a plugin or extension loaded by the application.
+type Plugin struct {
+	Name    string `json:"name"`
+	Module  string `json:"module,omitempty"`
+	Version string `json:"version"`
+	Warning string `json:"warning,omitempty"` // set if Version violates the host's constraint
+}
+
+var (
+	pluginsMu   sync.RWMutex
+	plugins     = map[string]Plugin{}
+	constraints = map[string]*Constraint{}
+)
+
+// RegisterPlugin registers the version of the plugin name, provided by the
+// module modulePath. An empty version means the version of modulePath
+// linked into the binary, see VersionOf, which suits extensions compiled
+// into the application:
+//
+//	version.RegisterPlugin("s3-storage", "github.com/me/s3plugin", "")
+//
+// Registered plugins are listed in Detail. Registering the same name again
+// replaces it.
+func RegisterPlugin(name, modulePath, version string) {
+	if version == "" {
+		version, _ = VersionOf(modulePath)
+	}
+
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	plugins[name] = Plugin{Name: name, Module: modulePath, Version: version}
+}
+
+// RequirePlugin sets the versions of the plugin name the host application
+// supports, e.g. ">=1.2, <2". Registered plugins not satisfying it are
+// listed with a warning instead of being rejected, since a skewed plugin
+// often still works well enough to be diagnosed.
+func RequirePlugin(name, constraint string) error {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return err
+	}
+
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	constraints[name] = c
+
+	return nil
+}
+
+// Plugins returns all registered plugins sorted by name, with warnings for
+// those violating the constraints set by RequirePlugin.
+func Plugins() []Plugin {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	list := make([]Plugin, 0, len(plugins))
+	for _, p := range plugins {
+		if c, ok := constraints[p.Name]; ok {
+			p.Warning = pluginWarning(p.Version, c)
+		}
+		list = append(list, p)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+func pluginWarning(version string, c *Constraint) string {
+	v, err := Parse(version)
+	switch {
+	case version == "":
+		return "unknown version, required " + c.String()
+	case err != nil:
+		return "development build, required " + c.String()
+	case !c.Check(v):
+		return "unsupported version, required " + c.String()
+	}
+
+	return ""
+}
@@ -0,0 +1,23 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly) || tinygo
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly tinygo
+
+package version
+
+import "os"
+
+// processAlive reports whether a process with the given PID is running. On
+// Windows os.FindProcess fails for processes that are gone; where it can't
+// tell, the process counts as running.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+
+	return true
+}
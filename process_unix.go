@@ -0,0 +1,21 @@
+//go:build (linux || darwin || freebsd || netbsd || openbsd || dragonfly) && !tinygo
+// +build linux darwin freebsd netbsd openbsd dragonfly
+// +build !tinygo
+
+package version
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive reports whether a process with the given PID is running.
+// A process of another user counts as running.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
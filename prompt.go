@@ -0,0 +1,75 @@
+package version
+
+import (
+	"strings"
+	"sync"
+)
+
+var prompt struct {
+	once sync.Once
+	text string
+}
+
+// VersionForPrompt returns a short version for tools embedded in shell
+// prompts: the tag of releases, e.g. "v1.2.3", the base tag and commit of
+// pseudo versions, e.g. "v1.2.2+abc1234", the commit alone for untagged
+// builds, or "devel". A "*" is appended for builds from a dirty working
+// copy. The result is computed once and cached, without templates or
+// names to resolve, so calling it costs next to nothing.
+func VersionForPrompt() string {
+	prompt.once.Do(func() {
+		prompt.text = promptVersion()
+	})
+
+	return prompt.text
+}
+
+func promptVersion() string {
+	info, ok := readBuildInfo()
+	if !ok {
+		return "devel"
+	}
+
+	var revision string
+	dirty := false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+
+	v := info.Main.Version
+	if i := strings.Index(v, "+dirty"); i >= 0 {
+		v, dirty = v[:i], true
+	}
+
+	text := "devel"
+	switch mv := GetAppVersion(v); {
+	case v == "" || mv == nil || mv.Type == Devel:
+		if revision != "" {
+			text = shortCommit(revision)
+		}
+	case mv.Type == PseudoBaseNoTag:
+		text = shortCommit(mv.CommitID)
+	case mv.Type == PseudoBaseRelease || mv.Type == PseudoBasePreRelease:
+		text = mv.Tag + "+" + shortCommit(mv.CommitID)
+	default:
+		text = v
+	}
+
+	if dirty {
+		text += "*"
+	}
+
+	return text
+}
+
+func shortCommit(id string) string {
+	if len(id) > 7 {
+		return id[:7]
+	}
+	return id
+}
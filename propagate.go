@@ -0,0 +1,80 @@
+package version
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Environment variables set by PropagateVersion.
+const (
+	ParentVersionEnv  = "GO_VERSION_PARENT"
+	ParentRevisionEnv = "GO_VERSION_PARENT_REVISION"
+)
+
+// PropagateVersion adds the version of the running binary to the
+// environment of cmd, before it's started, so that the child process, e.g.
+// a worker or plugin built from the same module, can detect that it runs
+// another version with CheckParent. A nil cmd.Env is taken as the
+// environment of the running process, like exec.Cmd does.
+func PropagateVersion(cmd *exec.Cmd) error {
+	d, err := GetDetail()
+	if err != nil {
+		return err
+	}
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env,
+		ParentVersionEnv+"="+d.AppVersion,
+		ParentRevisionEnv+"="+d.Revision)
+
+	return nil
+}
+
+// ParentSkewError is returned by CheckParent when a child process runs
+// another version than its parent, e.g. a worker pool whose binary was
+// upgraded on disk while the parent kept running.
+type ParentSkewError struct {
+	Parent, ParentRevision string
+	Self, SelfRevision     string
+}
+
+func (e *ParentSkewError) Error() string {
+	if e.Parent == e.Self {
+		return fmt.Sprintf("running %s built from %s, but the parent process runs it built from %s",
+			e.Self, e.SelfRevision, e.ParentRevision)
+	}
+
+	return fmt.Sprintf("running %s, but the parent process runs %s", e.Self, e.Parent)
+}
+
+// CheckParent compares the running binary to the version of the parent
+// process set by PropagateVersion, and returns a *ParentSkewError if they
+// differ. Development builds, which share a version like (devel), are
+// compared by revision. It returns nil if the parent didn't propagate its
+// version.
+func CheckParent() error {
+	parent, ok := os.LookupEnv(ParentVersionEnv)
+	if !ok {
+		return nil
+	}
+	parentRev := os.Getenv(ParentRevisionEnv)
+
+	d, err := GetDetail()
+	if err != nil {
+		return err
+	}
+
+	if parent == d.AppVersion && (parentRev == "" || parentRev == d.Revision) {
+		return nil
+	}
+
+	return &ParentSkewError{
+		Parent:         parent,
+		ParentRevision: parentRev,
+		Self:           d.AppVersion,
+		SelfRevision:   d.Revision,
+	}
+}
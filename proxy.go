@@ -0,0 +1,281 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ReleaseInfo describes a published version of a module.
+type ReleaseInfo struct {
+	Version string
+	Time    time.Time
+}
+
+var (
+	// ErrProxyOff is returned when GOPROXY is "off" or exhausted by it.
+	ErrProxyOff = errors.New("module lookup disabled by GOPROXY=off")
+
+	// ErrDirect is returned when a module must be fetched directly from its
+	// version control system, because of "direct" in GOPROXY or because it
+	// matches GONOPROXY/GOPRIVATE. ProxyClient doesn't speak to VCS.
+	ErrDirect = errors.New("module is only available directly from its VCS")
+)
+
+// ProxyClient is a client of the Go module proxy protocol. It follows the
+// same rules as cmd/go for GOPROXY, GONOPROXY and GOPRIVATE:
+//
+//   - proxies in GOPROXY are tried in order; after a proxy separated by a
+//     comma only 404 and 410 responses fall through to the next one, after a
+//     pipe any error does;
+//   - "off" disallows lookups, "direct" means the VCS, which ProxyClient
+//     doesn't support and reports as ErrDirect;
+//   - modules matching a pattern of GONOPROXY (GOPRIVATE if GONOPROXY is not
+//     set) are never looked up through a proxy.
+//
+// See also: https://go.dev/ref/mod#goproxy-protocol
+type ProxyClient struct {
+	Proxy   string       // GOPROXY list, default "https://proxy.golang.org,direct"
+	NoProxy string       // GONOPROXY patterns
+	Client  *http.Client // default RemoteOptions.Client
+}
+
+// NewProxyClient returns a ProxyClient configured from the environment.
+func NewProxyClient() *ProxyClient {
+	noProxy, ok := os.LookupEnv("GONOPROXY")
+	if !ok {
+		noProxy = os.Getenv("GOPRIVATE")
+	}
+
+	return &ProxyClient{
+		Proxy:   os.Getenv("GOPROXY"),
+		NoProxy: noProxy,
+	}
+}
+
+// Versions returns the tagged versions of modulePath known by the proxy, in
+// no particular order.
+func (c *ProxyClient) Versions(ctx context.Context, modulePath string) ([]string, error) {
+	data, err := c.get(ctx, modulePath, "@v/list")
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(string(data)), nil
+}
+
+// Info returns the metadata of one version of modulePath.
+func (c *ProxyClient) Info(ctx context.Context, modulePath, version string) (*ReleaseInfo, error) {
+	escaped, err := EscapePath(version)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.get(ctx, modulePath, "@v/"+escaped+".info")
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRelease(data)
+}
+
+// Zip downloads the source archive of one version of modulePath to w, as it
+// may be large: it's neither cached nor size limited, and only ctx limits
+// the time it takes.
+func (c *ProxyClient) Zip(ctx context.Context, modulePath, version string, w io.Writer) error {
+	escaped, err := EscapePath(version)
+	if err != nil {
+		return err
+	}
+
+	return c.each(ctx, modulePath, "@v/"+escaped+".zip", func(url string) (bool, error) {
+		n, err := download(ctx, c.Client, url, w)
+		// a partial download can't be retried from another proxy
+		return n == 0, err
+	})
+}
+
+// Latest returns the latest version of modulePath like `go get module@latest`:
+// the highest release version, else the highest pre-release version, else the
+// version the proxy reports as latest (typically a pseudo version).
+func (c *ProxyClient) Latest(ctx context.Context, modulePath string) (*ReleaseInfo, error) {
+	versions, err := c.Versions(ctx, modulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	best := ""
+	for _, v := range versions {
+		if _, err := Parse(v); err != nil {
+			continue
+		}
+		switch {
+		case best == "":
+			best = v
+		case isPrerelease(best) != isPrerelease(v):
+			if isPrerelease(best) {
+				best = v
+			}
+		case Compare(v, best) > 0:
+			best = v
+		}
+	}
+
+	if best != "" {
+		return c.Info(ctx, modulePath, best)
+	}
+
+	data, err := c.get(ctx, modulePath, "@latest")
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRelease(data)
+}
+
+func isPrerelease(v string) bool {
+	ver, err := Parse(v)
+	return err == nil && ver.Prerelease != ""
+}
+
+func decodeRelease(data []byte) (*ReleaseInfo, error) {
+	var r ReleaseInfo
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// get fetches the file named by suffix for modulePath, walking the GOPROXY
+// list.
+func (c *ProxyClient) get(ctx context.Context, modulePath, suffix string) ([]byte, error) {
+	var data []byte
+	err := c.each(ctx, modulePath, suffix, func(url string) (bool, error) {
+		var err error
+		data, err = fetchCached(ctx, c.Client, url, nil)
+		return true, err
+	})
+
+	return data, err
+}
+
+// each calls try with the URL of the file named by suffix for modulePath
+// on each proxy of the GOPROXY list, until it succeeds or an error doesn't
+// allow to fall back to the next proxy. try reports whether it may be
+// retried with the next proxy after a failure.
+func (c *ProxyClient) each(ctx context.Context, modulePath, suffix string, try func(url string) (bool, error)) error {
+	if matchPrefixPatterns(c.NoProxy, modulePath) {
+		return ErrDirect
+	}
+
+	escaped, err := EscapePath(modulePath)
+	if err != nil {
+		return err
+	}
+
+	list := c.Proxy
+	if list == "" {
+		list = "https://proxy.golang.org,direct"
+	}
+
+	var lastErr error
+	for list != "" {
+		proxy := list
+		fallbackOnAnyError := false
+		if i := strings.IndexAny(list, ",|"); i >= 0 {
+			proxy = list[:i]
+			fallbackOnAnyError = list[i] == '|'
+			list = list[i+1:]
+		} else {
+			list = ""
+		}
+
+		proxy = strings.TrimSpace(proxy)
+		switch proxy {
+		case "":
+			continue
+		case "off":
+			return ErrProxyOff
+		case "direct":
+			return ErrDirect
+		}
+
+		url := strings.TrimSuffix(proxy, "/") + "/" + escaped + "/" + suffix
+		retry, err := try(url)
+		if err == nil {
+			return nil
+		}
+
+		lastErr = err
+		if !retry || !fallbackOnAnyError && !isNotFound(err) {
+			return err
+		}
+	}
+
+	if lastErr == nil {
+		lastErr = ErrProxyOff
+	}
+
+	return lastErr
+}
+
+// EscapePath escapes upper case letters in module paths and versions as
+// required by the proxy protocol and done by the module cache, e.g.
+// "github.com/Azure" becomes "github.com/!azure".
+func EscapePath(s string) (string, error) {
+	var b strings.Builder
+
+	for _, r := range s {
+		switch {
+		case r == '!' || r >= 0x80 || r < ' ':
+			return "", fmt.Errorf("invalid character %q in %q", r, s)
+		case r >= 'A' && r <= 'Z':
+			b.WriteByte('!')
+			b.WriteRune(r + 'a' - 'A')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// matchPrefixPatterns reports whether any path prefix of target matches one
+// of the comma-separated glob patterns, like GOPRIVATE does.
+func matchPrefixPatterns(globs, target string) bool {
+	for _, glob := range strings.Split(globs, ",") {
+		glob = strings.TrimSuffix(strings.TrimSpace(glob), "/")
+		if glob == "" {
+			continue
+		}
+
+		n := strings.Count(glob, "/")
+		prefix := target
+		// cut target down to the same number of path elements as glob
+		for i := 0; i < len(target); i++ {
+			if target[i] == '/' {
+				if n == 0 {
+					prefix = target[:i]
+					break
+				}
+				n--
+			}
+		}
+		if n > 0 {
+			continue
+		}
+
+		if matched, _ := path.Match(glob, prefix); matched {
+			return true
+		}
+	}
+
+	return false
+}
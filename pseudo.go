@@ -0,0 +1,111 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MakePseudoVersion returns the pseudo version of a commit made at t,
+// following the algorithm of cmd/go, for release and mirroring tooling
+// which needs versions the go command accepts:
+//
+//	baseTag          result
+//	""               v0.0.0-20240102150405-abcdefabcdef
+//	"v2"             v2.0.0-20240102150405-abcdefabcdef
+//	"v1.2.3"         v1.2.4-0.20240102150405-abcdefabcdef
+//	"v1.2.3-rc.1"    v1.2.3-rc.1.0.20240102150405-abcdefabcdef
+//
+// baseTag is the latest tag the commit descends from, or the major
+// version alone, like "v2", for modules without a tag on their major
+// version; build metadata like +incompatible is kept. commit is the full
+// or abbreviated commit hash, of at least 12 hex digits, which is
+// shortened to 12 like cmd/go does. t is converted to UTC.
+func MakePseudoVersion(baseTag string, t time.Time, commit string) (string, error) {
+	commit = strings.ToLower(commit)
+	if len(commit) < 12 || strings.Trim(commit, "0123456789abcdef") != "" {
+		return "", fmt.Errorf("invalid commit %q: want at least 12 hex digits", commit)
+	}
+	segment := t.UTC().Format("20060102150405") + "-" + commit[:12]
+
+	major := "v0"
+	if baseTag != "" && !strings.Contains(baseTag, ".") {
+		major, baseTag = baseTag, ""
+		if !strings.HasPrefix(major, "v") || !isNumeric(major[1:]) {
+			return "", fmt.Errorf("%w %q: want a tag or a major version like v2", ErrInvalidVersion, major)
+		}
+	}
+	if baseTag == "" {
+		return major + ".0.0-" + segment, nil
+	}
+
+	base, err := Parse(baseTag)
+	if err != nil {
+		return "", err
+	}
+	build := ""
+	if base.Build != "" {
+		build = "+" + base.Build
+	}
+
+	if base.Prerelease != "" {
+		return fmt.Sprintf("v%d.%d.%d-%s.0.%s%s",
+			base.Major, base.Minor, base.Patch, base.Prerelease, segment, build), nil
+	}
+
+	if base.Patch == ^uint64(0) {
+		return "", fmt.Errorf("%w %q: patch version can't be incremented", ErrInvalidVersion, baseTag)
+	}
+
+	return "v" + strconv.FormatUint(base.Major, 10) + "." + strconv.FormatUint(base.Minor, 10) + "." +
+		strconv.FormatUint(base.Patch+1, 10) + "-0." + segment + build, nil
+}
+
+// BaseOf returns the tag the pseudo version is derived from, the reverse
+// of MakePseudoVersion: v1.2.3 for v1.2.4-0.20240102150405-abcdefabcdef,
+// and v1.2.3-rc.1 for v1.2.3-rc.1.0.20240102150405-abcdefabcdef. Build
+// metadata like +incompatible is kept. It returns "" for a pseudo version
+// of a commit without any tag before it, like
+// v0.0.0-20240102150405-abcdefabcdef, and an error for a version which
+// isn't a pseudo version.
+func BaseOf(version string) (string, error) {
+	v, err := Parse(version)
+	if err != nil {
+		return "", err
+	}
+
+	typ, tag, _, _ := pseudoBase(v)
+	switch typ {
+	case PseudoBaseNoTag:
+		return "", nil
+	case PseudoBaseRelease, PseudoBasePreRelease:
+		if v.Build != "" {
+			tag += "+" + v.Build
+		}
+		return tag, nil
+	}
+
+	return "", fmt.Errorf("%s is not a pseudo version", version)
+}
+
+// pseudoBase classifies v as one of the pseudo version types, with the tag
+// it's derived from, without build metadata, and its commit time and
+// commit, or else as PreRelease or Release.
+func pseudoBase(v *Version) (typ VersionType, tag string, t time.Time, commit string) {
+	base, t, commit, ok := splitPseudo(v.Prerelease)
+	switch {
+	case ok && base == "" && v.Minor == 0 && v.Patch == 0:
+		return PseudoBaseNoTag, "", t, commit
+	case ok && base == "0" && v.Patch > 0:
+		// the patch was incremented past the release tag
+		return PseudoBaseRelease, fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch-1), t, commit
+	case ok && base != "" && base != "0":
+		return PseudoBasePreRelease, fmt.Sprintf("v%d.%d.%d-%s", v.Major, v.Minor, v.Patch, base), t, commit
+	case v.Prerelease != "":
+		// including versions looking like invalid pseudo versions
+		return PreRelease, "", time.Time{}, ""
+	}
+
+	return Release, "", time.Time{}, ""
+}
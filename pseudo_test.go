@@ -0,0 +1,58 @@
+package version
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPseudoVersion(t *testing.T) {
+	commitTime := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	const commit = "abcdefabcdef0123456789abcdefabcdef012345"
+
+	tests := []struct {
+		name    string
+		baseTag string
+		pseudo  string
+		base    string // BaseOf(pseudo)
+	}{
+		{"no base", "", "v0.0.0-20240102150405-abcdefabcdef", ""},
+		{"v2", "v2", "v2.0.0-20240102150405-abcdefabcdef", ""},
+		{"release base", "v1.2.3", "v1.2.4-0.20240102150405-abcdefabcdef", "v1.2.3"},
+		{"pre-release base", "v1.2.3-rc.1", "v1.2.3-rc.1.0.20240102150405-abcdefabcdef", "v1.2.3-rc.1"},
+		{"incompatible", "v2.0.0+incompatible", "v2.0.1-0.20240102150405-abcdefabcdef+incompatible", "v2.0.0+incompatible"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MakePseudoVersion(tt.baseTag, commitTime.In(time.FixedZone("CET", 3600)), commit)
+			if err != nil {
+				t.Fatalf("MakePseudoVersion(%q) error: %v", tt.baseTag, err)
+			}
+			if got != tt.pseudo {
+				t.Errorf("MakePseudoVersion(%q) = %q, want %q", tt.baseTag, got, tt.pseudo)
+			}
+
+			base, err := BaseOf(tt.pseudo)
+			if err != nil {
+				t.Fatalf("BaseOf(%q) error: %v", tt.pseudo, err)
+			}
+			if base != tt.base {
+				t.Errorf("BaseOf(%q) = %q, want %q", tt.pseudo, base, tt.base)
+			}
+		})
+	}
+}
+
+func TestPseudoVersionErrors(t *testing.T) {
+	if _, err := MakePseudoVersion("v1.2.3", time.Now(), "abcdef"); err == nil {
+		t.Error("MakePseudoVersion accepted a short commit")
+	}
+	if _, err := MakePseudoVersion("2", time.Now(), "abcdefabcdef"); err == nil {
+		t.Error("MakePseudoVersion accepted a major version without v")
+	}
+	for _, v := range []string{"v1.2.3", "v1.2.3-rc.1", "garbage"} {
+		if _, err := BaseOf(v); err == nil {
+			t.Errorf("BaseOf(%q) accepted a version which isn't a pseudo version", v)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package version
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Results of a check of a ReadinessReport.
+const (
+	CheckPass = "pass"
+	CheckFail = "fail"
+	CheckSkip = "skip" // not enabled, see ReadinessOptions
+)
+
+// ReadinessCheck is the result of one check of Readiness.
+type ReadinessCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`           // CheckPass, CheckFail or CheckSkip
+	Detail string `json:"detail,omitempty"` // what was found
+}
+
+// ReadinessReport is the result of Readiness. It's marshalled to JSON as
+// is, and rendered as text by Render.
+type ReadinessReport struct {
+	File    string           `json:"file,omitempty"` // empty for the running binary
+	Version string           `json:"version"`
+	Checks  []ReadinessCheck `json:"checks"`
+}
+
+// ReadinessOptions configures ReadinessWith.
+type ReadinessOptions struct {
+	// File is the binary to check, e.g. a release artifact, instead of the
+	// running binary.
+	File string
+
+	// Vulns, if set, checks the modules and the Go toolchain for known
+	// vulnerabilities, e.g. an OSVChecker. The check is skipped otherwise.
+	Vulns VulnChecker
+}
+
+// Readiness checks whether the running binary may ship, for the ship
+// checklist of a release pipeline: it must be built from a clean working
+// copy, at a release tag, with -trimpath, and without replaced modules. See
+// ReadinessWith to check another binary or known vulnerabilities.
+func Readiness() (*ReadinessReport, error) {
+	return ReadinessWith(context.Background(), ReadinessOptions{})
+}
+
+// ReadinessWith is Readiness with options.
+func ReadinessWith(ctx context.Context, opts ReadinessOptions) (*ReadinessReport, error) {
+	info, ok := readBuildInfo()
+	if opts.File != "" {
+		report, err := Inspect(opts.File)
+		if err != nil {
+			return nil, err
+		}
+		info, ok = report.Info, report.Confidence >= ConfidenceHigh
+	}
+	if !ok {
+		return nil, ErrNoBuildInfo
+	}
+
+	d := newDetail(info)
+	r := &ReadinessReport{File: opts.File, Version: info.Main.Version}
+	r.Checks = append(r.Checks,
+		checkClean(d),
+		checkTagged(d),
+		checkReproducible(info),
+		checkReplaced(info),
+		checkVulns(ctx, info, opts.Vulns),
+	)
+
+	return r, nil
+}
+
+func checkClean(d *Detail) ReadinessCheck {
+	c := ReadinessCheck{Name: "clean working copy", Status: CheckPass}
+	switch {
+	case d.IsDirty:
+		c.Status, c.Detail = CheckFail, "built from a modified working tree"
+	case d.VCS == "unknown" && !d.IsRelease():
+		c.Status, c.Detail = CheckFail, "no version control information"
+	case d.VCS == "unknown":
+		c.Detail = "built from the module proxy"
+	}
+	return c
+}
+
+func checkTagged(d *Detail) ReadinessCheck {
+	c := ReadinessCheck{Name: "tagged release", Status: CheckPass, Detail: d.AppVersion}
+	if !d.IsRelease() {
+		c.Status, c.Detail = CheckFail, "not a release, "+d.TagRemarks
+	}
+	return c
+}
+
+func checkReproducible(info *debug.BuildInfo) ReadinessCheck {
+	c := ReadinessCheck{Name: "reproducible flags", Status: CheckPass}
+	for _, s := range info.Settings {
+		if s.Key == "-trimpath" && s.Value == "true" {
+			return c
+		}
+	}
+
+	c.Status, c.Detail = CheckFail, "built without -trimpath, the binary embeds local paths"
+	return c
+}
+
+func checkReplaced(info *debug.BuildInfo) ReadinessCheck {
+	var replaced []string
+	for _, dep := range info.Deps {
+		if dep.Replace == nil {
+			continue
+		}
+		to := dep.Replace.Path
+		if dep.Replace.Version != "" {
+			to += "@" + dep.Replace.Version
+		}
+		replaced = append(replaced, dep.Path+" => "+to)
+	}
+
+	if len(replaced) > 0 {
+		return ReadinessCheck{Name: "no replaced modules", Status: CheckFail, Detail: strings.Join(replaced, ", ")}
+	}
+	return ReadinessCheck{Name: "no replaced modules", Status: CheckPass}
+}
+
+func checkVulns(ctx context.Context, info *debug.BuildInfo, checker VulnChecker) ReadinessCheck {
+	c := ReadinessCheck{Name: "no known vulnerabilities", Status: CheckPass}
+	if checker == nil {
+		c.Status = CheckSkip
+		return c
+	}
+
+	vulns, err := checker.Vulnerabilities(ctx, info)
+	if err != nil {
+		// a check which couldn't run mustn't let the release through
+		c.Status, c.Detail = CheckFail, "check failed: "+err.Error()
+		return c
+	}
+
+	var found []string
+	for path, ids := range vulns {
+		if len(ids) > 0 {
+			found = append(found, path+" ("+strings.Join(ids, ", ")+")")
+		}
+	}
+	sort.Strings(found)
+	if len(found) > 0 {
+		c.Status, c.Detail = CheckFail, strings.Join(found, ", ")
+	}
+	return c
+}
+
+// OK reports whether no check failed.
+func (r *ReadinessReport) OK() bool {
+	for _, c := range r.Checks {
+		if c.Status == CheckFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Render writes the report as a table, one line per check, followed by the
+// verdict.
+func (r *ReadinessReport) Render(w io.Writer) error {
+	ew := &errWriter{w: w}
+
+	tw := tabwriter.NewWriter(ew, 0, 4, 2, ' ', 0)
+	for _, c := range r.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Status, c.Name, c.Detail)
+	}
+	tw.Flush()
+
+	if ew.err == nil {
+		if r.OK() {
+			fmt.Fprintf(ew, "\n%s is ready to ship.\n", r.Version)
+		} else {
+			fmt.Fprintf(ew, "\n%s is not ready to ship.\n", r.Version)
+		}
+	}
+
+	return ew.err
+}
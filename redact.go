@@ -0,0 +1,92 @@
+package version
+
+import (
+	"path"
+	"runtime/debug"
+	"sync"
+)
+
+// Redacted replaces the values of build settings hidden by RedactKeys.
+const Redacted = "[redacted]"
+
+// Redactor is called for each build setting before it is used by this
+// package. It returns the value to expose, and false to drop the setting
+// altogether.
+type Redactor func(key, value string) (string, bool)
+
+var (
+	redactorMu sync.RWMutex
+	redactor   Redactor
+)
+
+// SetRedactor installs r to redact build settings that leak internal paths
+// or URLs, such as -ldflags with private hosts or CGO_CFLAGS with include
+// paths. It applies to all build info read by this package, and so to any
+// rendering, JSON export, event or template (including .Raw). A nil r, the
+// default, exposes all settings.
+func SetRedactor(r Redactor) {
+	redactorMu.Lock()
+	defer redactorMu.Unlock()
+
+	redactor = r
+}
+
+// RedactKeys returns a Redactor replacing the values of settings whose key
+// matches one of patterns with Redacted. Patterns use the syntax of
+// path.Match, e.g.
+//
+//	version.SetRedactor(version.RedactKeys("-ldflags", "CGO_*", "-pgo"))
+func RedactKeys(patterns ...string) Redactor {
+	return func(key, value string) (string, bool) {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, key); ok {
+				return Redacted, true
+			}
+		}
+		return value, true
+	}
+}
+
+// readBuildInfo returns the build information of the running binary, with
+// the settings redacted by the Redactor set by SetRedactor, and under the
+// embargo of white-label builds, see EmbargoPath.
+func readBuildInfo() (*debug.BuildInfo, bool) {
+	info, ok := readRedactedBuildInfo()
+	if !ok {
+		return nil, false
+	}
+
+	return applyEmbargo(info), true
+}
+
+// readRedactedBuildInfo is readBuildInfo without the embargo.
+func readRedactedBuildInfo() (*debug.BuildInfo, bool) {
+	info, ok := loadBuildInfo()
+	if !ok {
+		return nil, false
+	}
+
+	return redactBuildInfo(info), true
+}
+
+// redactBuildInfo returns info with its settings redacted by the Redactor
+// set by SetRedactor.
+func redactBuildInfo(info *debug.BuildInfo) *debug.BuildInfo {
+	redactorMu.RLock()
+	r := redactor
+	redactorMu.RUnlock()
+
+	if r == nil {
+		return info
+	}
+
+	redacted := *info
+	redacted.Settings = make([]debug.BuildSetting, 0, len(info.Settings))
+	for _, s := range info.Settings {
+		if value, keep := r(s.Key, s.Value); keep {
+			redacted.Settings = append(redacted.Settings, debug.BuildSetting{Key: s.Key, Value: value})
+		}
+	}
+
+	return &redacted
+}
@@ -0,0 +1,368 @@
+package version
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout is the default time limit of a single remote call.
+const DefaultTimeout = 5 * time.Second
+
+// Limiter limits the rate of remote calls. Wait blocks until a call may
+// proceed, or returns an error if ctx is done first. *rate.Limiter of
+// golang.org/x/time/rate satisfies it.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// RemoteOptions controls how all network-backed features of this package
+// talk to remote services, so that embedding it can neither slow down the
+// start of an application nor hammer a forge API.
+type RemoteOptions struct {
+	Timeout time.Duration // limit of each call, including the wait for Limiter, 0 means DefaultTimeout, negative means none
+	Limiter Limiter       // shared by all calls, nil means unlimited
+
+	// Client makes the calls that are not given a client of their own,
+	// e.g. by ProxyClient.Client, so that corporate proxies, mTLS or
+	// instrumented transports apply everywhere. nil means
+	// http.DefaultClient.
+	Client *http.Client
+}
+
+var (
+	remoteMu   sync.RWMutex
+	remoteOpts RemoteOptions
+)
+
+// SetRemoteOptions sets the options used by all remote calls. Each call
+// also honors the deadline and cancellation of the context it's given.
+func SetRemoteOptions(opts RemoteOptions) {
+	remoteMu.Lock()
+	defer remoteMu.Unlock()
+
+	remoteOpts = opts
+}
+
+func currentRemoteOptions() RemoteOptions {
+	remoteMu.RLock()
+	defer remoteMu.RUnlock()
+
+	return remoteOpts
+}
+
+// NewLimiter returns a token bucket Limiter which allows rate calls per
+// second on average, and bursts of up to burst calls. It panics if rate is
+// not positive; use a nil Limiter for unlimited calls.
+func NewLimiter(rate float64, burst int) Limiter {
+	if rate <= 0 {
+		panic("version: non-positive rate for NewLimiter")
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// Wait implements Limiter.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		// don't wait for a call which can't be made in time anyway
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return errLimitDeadline
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// errLimitDeadline is returned by Wait of NewLimiter if the call would have
+// to wait past the deadline of its context.
+var errLimitDeadline = fmt.Errorf("rate limit wait would exceed the deadline: %w", context.DeadlineExceeded)
+
+// ErrOffline is returned by every feature that needs the network while the
+// package is in offline mode.
+var ErrOffline = errors.New("network access disabled by offline mode")
+
+var offline = boolToInt32(offlineFromEnv())
+
+// SetOffline switches the offline mode. In offline mode every feature of this
+// package that touches the network, such as update checks, returns ErrOffline
+// immediately, so the package is safe to use in air-gapped deployments.
+//
+// The initial mode is taken from the environment: offline mode is on if
+// GO_VERSION_OFFLINE is true, if GOPROXY is "off", or if GOFLAGS contains
+// -mod=vendor.
+func SetOffline(on bool) {
+	atomic.StoreInt32(&offline, boolToInt32(on))
+}
+
+// IsOffline reports whether the offline mode is on.
+func IsOffline() bool {
+	return atomic.LoadInt32(&offline) != 0
+}
+
+func offlineFromEnv() bool {
+	if on, err := strconv.ParseBool(os.Getenv("GO_VERSION_OFFLINE")); err == nil {
+		return on
+	}
+
+	if strings.TrimSpace(os.Getenv("GOPROXY")) == "off" {
+		return true
+	}
+
+	for _, flag := range strings.Fields(os.Getenv("GOFLAGS")) {
+		if flag == "-mod=vendor" || flag == "--mod=vendor" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func boolToInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// maxResponseSize limits the size of responses read from remote services.
+const maxResponseSize = 16 << 20
+
+// statusError is returned by doRequest for unsuccessful HTTP responses.
+type statusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// isNotFound reports whether err is a 404 or 410 response.
+func isNotFound(err error) bool {
+	se, ok := err.(*statusError)
+	return ok && (se.StatusCode == http.StatusNotFound || se.StatusCode == http.StatusGone)
+}
+
+// fetch GETs url with the given extra header and returns the body. A nil
+// client means the client of RemoteOptions, or http.DefaultClient.
+func fetch(ctx context.Context, client *http.Client, url string, header http.Header) ([]byte, error) {
+	return doRequest(ctx, client, http.MethodGet, url, header, nil)
+}
+
+// post POSTs body to url with the given content type and returns the
+// response body. Any 2xx response is a success.
+func post(ctx context.Context, client *http.Client, url, contentType string, body []byte) ([]byte, error) {
+	header := http.Header{"Content-Type": {contentType}}
+	return doRequest(ctx, client, http.MethodPost, url, header, body)
+}
+
+// doRequest sends a request honoring the offline mode and RemoteOptions.
+func doRequest(ctx context.Context, client *http.Client, method, url string, header http.Header, body []byte) ([]byte, error) {
+	// the timeout includes the wait for the limiter
+	timeout := currentRemoteOptions().Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	client, err := remoteClient(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	ok := resp.StatusCode == http.StatusOK
+	if method != http.MethodGet {
+		ok = resp.StatusCode >= 200 && resp.StatusCode < 300
+	}
+	if !ok {
+		return nil, &statusError{Method: method, URL: url, StatusCode: resp.StatusCode}
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+}
+
+// remoteClient returns the client to use for a remote call, after waiting
+// for the limiter.
+func remoteClient(ctx context.Context, client *http.Client) (*http.Client, error) {
+	opts := currentRemoteOptions()
+
+	if IsOffline() {
+		return nil, ErrOffline
+	}
+
+	if client == nil {
+		client = opts.Client
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	if opts.Limiter != nil {
+		if err := opts.Limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+// download GETs url and copies the body to w, returning the number of
+// bytes copied. Unlike fetch, the body is neither size limited nor subject
+// to RemoteOptions.Timeout, as downloading a binary may take a while; only
+// ctx limits it.
+func download(ctx context.Context, client *http.Client, url string, w io.Writer) (int64, error) {
+	client, err := remoteClient(ctx, client)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, &statusError{Method: http.MethodGet, URL: url, StatusCode: resp.StatusCode}
+	}
+
+	return io.Copy(w, resp.Body)
+}
+
+// fetchCached is fetch with the results kept in the cache set by SetCache.
+// Only successful responses are cached. Documents of the bundle set by
+// SetBundle are returned as is.
+func fetchCached(ctx context.Context, client *http.Client, url string, header http.Header) ([]byte, error) {
+	if data, ok := bundleResponse(url); ok {
+		return data, nil
+	}
+
+	if IsOffline() {
+		return nil, ErrOffline
+	}
+
+	c, ttl := currentCache()
+	if c == nil || ttl <= 0 {
+		return fetch(ctx, client, url, header)
+	}
+
+	key := "GET " + url
+	if cred := credentialHash(header); cred != "" {
+		key += " " + cred
+	}
+	if data, ok := c.Get(key); ok {
+		return data, nil
+	}
+
+	data, err := fetch(ctx, client, url, header)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Set(key, data, ttl)
+
+	return data, nil
+}
+
+// credentialHeaders are the request headers carrying credentials.
+var credentialHeaders = []string{"Authorization", "PRIVATE-TOKEN"}
+
+// credentialHash returns a hash of the credentials in header, or "" if there
+// are none, so that responses cached for one credential aren't returned for
+// another or for none.
+func credentialHash(header http.Header) string {
+	h := sha256.New()
+	found := false
+	for _, name := range credentialHeaders {
+		for _, v := range header.Values(name) {
+			fmt.Fprintf(h, "%s: %s\n", name, v)
+			found = true
+		}
+	}
+	if !found {
+		return ""
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,372 @@
+package version
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ScanError is an error reading a file found by ScanDir.
+type ScanError struct {
+	File string
+	Err  error
+}
+
+func (e *ScanError) Error() string {
+	return e.File + ": " + e.Err.Error()
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// ScanErrors is the list of files ScanDir failed to read.
+type ScanErrors []*ScanError
+
+func (e ScanErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// ScanOptions controls ScanDirWith.
+type ScanOptions struct {
+	// Concurrency is the number of files inspected in parallel, default
+	// runtime.GOMAXPROCS(0).
+	Concurrency int
+
+	// Progress, if set, is called after each file is scanned, e.g. to drive
+	// a progress bar. Calls are serialized and the counts only grow.
+	Progress func(ScanProgress)
+}
+
+// ScanProgress is the state of a scan reported to ScanOptions.Progress.
+type ScanProgress struct {
+	Files    int // files scanned so far
+	Binaries int // Go binaries found so far, not counting links to them
+	Errors   int // files that couldn't be read
+}
+
+// ScanDir inspects the Go binaries in the directory tree rooted at dir, e.g.
+// to take an inventory of the tools installed on a host. Files which are not
+// executables, or executables which show no sign of being built by Go, are
+// skipped.
+//
+// Every binary is reported once, however many paths lead to it: symlinks
+// and hard links to a binary already found are added to its Links instead of
+// being reported again, so inventories don't double-count. Symlinks to
+// directories are not followed.
+//
+// Files that can't be read are reported in a ScanErrors, along with the
+// binaries that could. The reports are in the order of the walk.
+func ScanDir(dir string) ([]*BinaryReport, error) {
+	return ScanDirWith(dir, ScanOptions{})
+}
+
+// ScanDirWith is like ScanDir, with options for long scans over thousands of
+// files.
+func ScanDirWith(dir string, opts ScanOptions) ([]*BinaryReport, error) {
+	var (
+		reports []*BinaryReport
+		seqs    []int
+		byFile  = map[string]*BinaryReport{}
+		errs    ScanErrors
+	)
+
+	for r := range ScanDirStream(context.Background(), dir, opts) {
+		switch {
+		case r.Report != nil:
+			reports = append(reports, r.Report)
+			seqs = append(seqs, r.seq)
+			byFile[r.Report.File] = r.Report
+		case r.Link != "":
+			byFile[r.LinkOf].Links = append(byFile[r.LinkOf].Links, r.Link)
+		case r.Err != nil && r.Err.File == dir:
+			return nil, r.Err.Err
+		case r.Err != nil:
+			errs = append(errs, r.Err)
+		}
+	}
+
+	// reports arrive in the order their inspections end
+	sort.Sort(bySeq{reports, seqs})
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].File < errs[j].File })
+		return reports, errs
+	}
+
+	return reports, nil
+}
+
+type bySeq struct {
+	reports []*BinaryReport
+	seqs    []int
+}
+
+func (s bySeq) Len() int           { return len(s.reports) }
+func (s bySeq) Less(i, j int) bool { return s.seqs[i] < s.seqs[j] }
+func (s bySeq) Swap(i, j int) {
+	s.reports[i], s.reports[j] = s.reports[j], s.reports[i]
+	s.seqs[i], s.seqs[j] = s.seqs[j], s.seqs[i]
+}
+
+// ScanResult is a result of ScanDirStream: a Go binary, a further path of
+// a binary sent before, or a file that couldn't be read.
+type ScanResult struct {
+	Report *BinaryReport // a Go binary, with the links to it found so far
+	Link   string        // a further path, symlink or hard link, of ...
+	LinkOf string        // ... the binary at this Report.File
+	Err    *ScanError    // a file that couldn't be read, or dir itself
+
+	seq int // walk order of Report
+}
+
+// ScanDirStream is ScanDir sending its results one by one, as soon as they
+// are known, so that callers can process huge inventories incrementally
+// instead of holding them in memory:
+//
+//	for r := range version.ScanDirStream(ctx, "/", version.ScanOptions{}) {
+//		if r.Report != nil {
+//			fmt.Println(r.Report.File, r.Report.Info.Main.Version)
+//		}
+//	}
+//
+// Reports are sent in the order their inspections end. Links to a binary
+// found before it is sent are in its Links, later ones are sent on their
+// own. The channel is closed when the scan is done, or soon after ctx is
+// done; the caller must keep receiving until then.
+func ScanDirStream(ctx context.Context, dir string, opts ScanOptions) <-chan ScanResult {
+	out := make(chan ScanResult)
+	go func() {
+		defer close(out)
+		scanDir(ctx, dir, opts, out)
+	}()
+
+	return out
+}
+
+// scanEntry is a file being scanned by scanDir.
+type scanEntry struct {
+	file string
+	info os.FileInfo // only without a fileID, for os.SameFile
+	seq  int
+
+	mu     sync.Mutex
+	done   bool
+	binary bool     // a report was sent, which isn't kept to save memory
+	links  []string // found before the inspection was done
+}
+
+// fileID identifies a file by device and inode, see fileIDOf.
+type fileID struct {
+	dev, ino uint64
+}
+
+func scanDir(ctx context.Context, dir string, opts ScanOptions, out chan<- ScanResult) {
+	n := opts.Concurrency
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+
+	var (
+		mu       sync.Mutex // guards progress
+		progress ScanProgress
+	)
+
+	send := func(r ScanResult) {
+		select {
+		case out <- r:
+		case <-ctx.Done():
+		}
+	}
+
+	scanned := func(binary bool, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		progress.Files++
+		if binary {
+			progress.Binaries++
+		}
+		if err != nil {
+			progress.Errors++
+		}
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+
+	files := make(chan *scanEntry)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range files {
+				report, err := inspectExecutable(e.file)
+				scanned(report != nil, err)
+				if err != nil {
+					send(ScanResult{Err: &ScanError{File: e.file, Err: err}})
+				}
+
+				e.mu.Lock()
+				e.done, e.binary = true, report != nil
+				if report != nil {
+					report.Links = e.links
+					send(ScanResult{Report: report, seq: e.seq})
+				}
+				e.links = nil
+				e.mu.Unlock()
+			}
+		}()
+	}
+
+	var (
+		seq    int
+		byID   = map[fileID]*scanEntry{}
+		others []*scanEntry // without a fileID, compared with os.SameFile
+	)
+
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if file == dir {
+				return err
+			}
+			scanned(false, err)
+			send(ScanResult{Err: &ScanError{File: file, Err: err}})
+			return nil
+		}
+		if d.IsDir() || !(d.Type().IsRegular() || d.Type()&fs.ModeSymlink != 0) {
+			return nil
+		}
+
+		fi, err := os.Stat(file)
+		if err != nil {
+			scanned(false, err)
+			send(ScanResult{Err: &ScanError{File: file, Err: err}})
+			return nil
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		// hard links and symlinks to a file scanned before are only
+		// reported as its links
+		id, hasID := fileIDOf(fi)
+		var seen *scanEntry
+		if hasID {
+			seen = byID[id]
+		} else {
+			for _, e := range others {
+				if os.SameFile(fi, e.info) {
+					seen = e
+					break
+				}
+			}
+		}
+		if seen != nil {
+			scanned(false, nil)
+
+			seen.mu.Lock()
+			switch {
+			case !seen.done:
+				seen.links = append(seen.links, file)
+			case seen.binary:
+				send(ScanResult{Link: file, LinkOf: seen.file})
+			}
+			seen.mu.Unlock()
+
+			return nil
+		}
+
+		e := &scanEntry{file: file, seq: seq}
+		seq++
+		if hasID {
+			byID[id] = e
+		} else {
+			e.info = fi
+			others = append(others, e)
+		}
+
+		select {
+		case files <- e:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		return nil
+	})
+
+	close(files)
+	wg.Wait()
+
+	if err != nil && ctx.Err() == nil {
+		send(ScanResult{Err: &ScanError{File: dir, Err: err}})
+	}
+}
+
+// executableMagics are the headers of ELF, Mach-O (32 and 64 bit, both
+// byte orders), PE and WebAssembly files.
+var executableMagics = [][]byte{
+	[]byte("\x7fELF"),
+	[]byte("\xfe\xed\xfa\xce"), []byte("\xce\xfa\xed\xfe"),
+	[]byte("\xfe\xed\xfa\xcf"), []byte("\xcf\xfa\xed\xfe"),
+	[]byte("MZ"),
+	[]byte("\x00asm"),
+}
+
+// inspectExecutable inspects file if it's an executable built by Go, and
+// returns nil otherwise.
+func inspectExecutable(file string) (*BinaryReport, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 4)
+	_, err = io.ReadFull(f, header)
+	f.Close()
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	isExecutable := false
+	for _, magic := range executableMagics {
+		if bytes.HasPrefix(header, magic) {
+			isExecutable = true
+			break
+		}
+	}
+	if !isExecutable {
+		return nil, nil
+	}
+
+	report, err := Inspect(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// version-like strings alone are found in plenty of C programs
+	if report.Confidence < ConfidenceMedium && report.Info.GoVersion == "" {
+		return nil, nil
+	}
+
+	return report, nil
+}
@@ -0,0 +1,46 @@
+//go:build go1.23
+// +build go1.23
+
+package version
+
+import (
+	"context"
+	"iter"
+)
+
+// ScanDirSeq is ScanDirStream as an iterator over the Go binaries found and
+// the errors, either of which is nil:
+//
+//	for report, err := range version.ScanDirSeq(ctx, "/opt", version.ScanOptions{}) {
+//		...
+//	}
+//
+// Links found after their binary was yielded are dropped, use ScanDirStream
+// if they matter. Breaking out of the loop cancels the scan.
+func ScanDirSeq(ctx context.Context, dir string, opts ScanOptions) iter.Seq2[*BinaryReport, error] {
+	return func(yield func(*BinaryReport, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := ScanDirStream(ctx, dir, opts)
+		defer func() {
+			for range results {
+			}
+		}()
+
+		for r := range results {
+			var ok bool
+			switch {
+			case r.Report != nil:
+				ok = yield(r.Report, nil)
+			case r.Err != nil:
+				ok = yield(nil, r.Err)
+			default:
+				continue
+			}
+			if !ok {
+				return
+			}
+		}
+	}
+}
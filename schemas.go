@@ -0,0 +1,48 @@
+package version
+
+import (
+	"sort"
+	"sync"
+)
+
+// Schema is a data format version a build expects, such as the version of
+// its configuration file or the last database migration.
+type Schema struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Since   string `json:"since,omitempty"` // binary version that introduced this schema version
+}
+
+var (
+	schemasMu sync.RWMutex
+	schemas   = map[string]Schema{}
+)
+
+// RegisterSchema declares that the build expects version of the data format
+// name, introduced by the binary version since, e.g.
+//
+//	version.RegisterSchema("config", "3", "v1.2.0")
+//	version.RegisterSchema("db-migration", "42", "v1.4.0")
+//
+// Registered schemas are listed in Detail, so operators can see which
+// on-disk formats a build expects. since may be empty if unknown.
+func RegisterSchema(name, version, since string) {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+
+	schemas[name] = Schema{Name: name, Version: version, Since: since}
+}
+
+// Schemas returns all registered schemas, sorted by name.
+func Schemas() []Schema {
+	schemasMu.RLock()
+	list := make([]Schema, 0, len(schemas))
+	for _, s := range schemas {
+		list = append(list, s)
+	}
+	schemasMu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
@@ -0,0 +1,62 @@
+package version
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// SDKVersionHeader is the name of the header set by SetSDKVersion.
+const SDKVersionHeader = "X-SDK-Version"
+
+// SDKVersion returns the composite version string cloud SDKs send to their
+// services, made of the library modulePath, the application embedding it
+// and the Go version, e.g. "mylib/1.2.3 app/2.0.1 go/1.22.1". See VersionOf
+// for how the version of the library is found; if it's not linked in, its
+// part is left out.
+func SDKVersion(modulePath string) string {
+	info, ok := readBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	var parts []string
+	if v, ok := versionOf(info, modulePath); ok {
+		parts = append(parts, productName(modulePath)+"/"+productVersion(v))
+	}
+
+	d := newDetail(info)
+	if info.Main.Path != modulePath {
+		parts = append(parts, d.AppName+"/"+productVersion(d.AppVersion))
+	}
+	parts = append(parts, "go/"+strings.TrimPrefix(d.GoVersion, "go"))
+
+	return strings.Join(parts, " ")
+}
+
+// SetSDKVersion sets the SDKVersionHeader of h to SDKVersion(modulePath).
+func SetSDKVersion(h http.Header, modulePath string) {
+	if v := SDKVersion(modulePath); v != "" {
+		h.Set(SDKVersionHeader, v)
+	}
+}
+
+// productName returns the name of a module for a product token: its last
+// path element, skipping a major version suffix, e.g. "mylib" for
+// "github.com/me/mylib/v2".
+func productName(modulePath string) string {
+	name := path.Base(modulePath)
+	if isMajorSuffix(name) {
+		name = path.Base(path.Dir(modulePath))
+	}
+	return name
+}
+
+// productVersion returns a version without the leading "v", and "devel"
+// for "(devel)", since parentheses are not allowed in product tokens.
+func productVersion(v string) string {
+	if v == "" || v == "(devel)" {
+		return "devel"
+	}
+	return strings.TrimPrefix(v, "v")
+}
@@ -0,0 +1,268 @@
+package version
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Patcher applies binary patches, e.g. bsdiff patches or zstd
+// --patch-from patches. This package ships no patch format, so as not to
+// depend on one; wrap the library of the format the release tooling
+// produces.
+type Patcher interface {
+	// Patch writes to new the result of applying patch to old, the
+	// running executable.
+	Patch(old, patch io.Reader, new io.Writer) error
+}
+
+// PatcherFunc is an adapter to use ordinary functions as Patcher.
+type PatcherFunc func(old, patch io.Reader, new io.Writer) error
+
+// Patch implements Patcher.
+func (f PatcherFunc) Patch(old, patch io.Reader, new io.Writer) error {
+	return f(old, patch, new)
+}
+
+// Update is the target of a self-update.
+type Update struct {
+	URL string // of the full binary
+
+	// Version is the version being installed. If set, the build info of
+	// the new binary is checked to carry it once it's in place, see
+	// VerifyStamp, and the update is rolled back if it doesn't.
+	Version string
+
+	// SHA256 is the hex digest of the new binary, e.g. taken from
+	// SignedRelease.Artifacts. It's required: it's all that keeps a broken
+	// patch or a tampered download from replacing the executable.
+	SHA256 string
+
+	// PatchURL is the URL of a patch from the running version to the new
+	// one, optional. It's only used with a Patcher.
+	PatchURL string
+}
+
+// Updater replaces the running executable by a new release. It applies a
+// patch against the running executable when one is available, which is
+// much smaller than the full binary for frequent updates, and falls back
+// to downloading the full binary if there is none, or it fails.
+//
+// The previous executable is kept with a .bak suffix, e.g. app.bak, so
+// that Rollback can restore it.
+type Updater struct {
+	Patcher    Patcher      // nil means full downloads only
+	Executable string       // the file to replace, default os.Executable()
+	Client     *http.Client // default RemoteOptions.Client
+
+	// Probe, if set, checks the new binary once it's in place, e.g. by
+	// running it with VerifyStampOutput or against a health check; the
+	// update is rolled back if it fails. Probes which only the restarted
+	// application can make call Rollback themselves.
+	Probe func(ctx context.Context, file string) error
+}
+
+// UpdateReport tells how an update was applied.
+type UpdateReport struct {
+	File       string `json:"file"`
+	Version    string `json:"version,omitempty"`
+	Patched    bool   `json:"patched"`    // applied as a patch rather than a full download
+	Downloaded int64  `json:"downloaded"` // bytes downloaded, including failed patches
+	Backup     string `json:"backup,omitempty"`
+	RolledBack bool   `json:"rolledBack,omitempty"`
+	PatchError error  `json:"-"` // why the patch wasn't used, if one was tried
+}
+
+// Apply installs up. The new binary is written next to the executable and
+// checked against up.SHA256 before it replaces the executable, so a failed
+// download leaves the executable untouched. Once in place, the new binary
+// is checked to carry up.Version and to pass the probe, and rolled back
+// otherwise, in which case the error tells why and report.RolledBack is
+// set. The running process keeps running the old version until it's
+// restarted.
+func (u *Updater) Apply(ctx context.Context, up Update) (*UpdateReport, error) {
+	if up.SHA256 == "" {
+		return nil, errors.New("self-update needs the SHA-256 of the new binary")
+	}
+
+	file, err := u.executable()
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UpdateReport{File: file, Version: up.Version}
+
+	tmp := ""
+	if up.PatchURL != "" && u.Patcher != nil {
+		tmp, err = writeVerified(file, up.SHA256, func(w io.Writer) error {
+			return u.patch(ctx, file, up.PatchURL, w, report)
+		})
+		if err != nil {
+			report.PatchError = err
+		} else {
+			report.Patched = true
+		}
+	}
+
+	if tmp == "" {
+		tmp, err = writeVerified(file, up.SHA256, func(w io.Writer) error {
+			n, err := download(ctx, u.Client, up.URL, w)
+			report.Downloaded += n
+			return err
+		})
+		if err != nil {
+			return report, err
+		}
+	}
+
+	err = os.Chmod(tmp, fi.Mode().Perm())
+	if err == nil {
+		err = swapExecutable(file, tmp, backupFile(file))
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return report, err
+	}
+	report.Backup = backupFile(file)
+
+	if up.Version != "" {
+		err = VerifyStamp(file, up.Version)
+	}
+	if err == nil && u.Probe != nil {
+		err = u.Probe(ctx, file)
+	}
+	if err != nil {
+		if rerr := u.Rollback(); rerr != nil {
+			return report, fmt.Errorf("%v; rollback failed: %w", err, rerr)
+		}
+		report.Backup, report.RolledBack = "", true
+		return report, fmt.Errorf("update rolled back: %w", err)
+	}
+
+	return report, nil
+}
+
+// Rollback restores the executable saved by the last update, e.g. when
+// the new version fails a health check after the restart.
+func (u *Updater) Rollback() error {
+	file, err := u.executable()
+	if err != nil {
+		return err
+	}
+
+	backup := backupFile(file)
+	if _, err := os.Stat(backup); err != nil {
+		return fmt.Errorf("no previous executable to roll back to: %w", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		return os.Rename(backup, file)
+	}
+
+	// see swapExecutable
+	failed := file + ".old"
+	os.Remove(failed)
+	if err := os.Rename(file, failed); err != nil {
+		return err
+	}
+	if err := os.Rename(backup, file); err != nil {
+		os.Rename(failed, file)
+		return err
+	}
+
+	return nil
+}
+
+func (u *Updater) executable() (string, error) {
+	file := u.Executable
+	if file == "" {
+		var err error
+		if file, err = os.Executable(); err != nil {
+			return "", err
+		}
+	}
+
+	// replace the file, not a symlink to it
+	return filepath.EvalSymlinks(file)
+}
+
+func (u *Updater) patch(ctx context.Context, file, url string, w io.Writer, report *UpdateReport) error {
+	var patch bytes.Buffer
+	n, err := download(ctx, u.Client, url, &patch)
+	report.Downloaded += n
+	if err != nil {
+		return err
+	}
+
+	old, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer old.Close()
+
+	return u.Patcher.Patch(old, &patch, w)
+}
+
+// writeVerified writes a temporary file next to file with write, and
+// returns its name if its SHA-256 is sum. The file is removed on failure.
+func writeVerified(file, sum string, write func(w io.Writer) error) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+"-*")
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	err = write(io.MultiWriter(tmp, h))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); err == nil && !strings.EqualFold(got, sum) {
+		err = fmt.Errorf("new binary has SHA-256 %s, want %s", got, sum)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	return tmp.Name(), nil
+}
+
+func backupFile(file string) string {
+	return strings.TrimSuffix(file, ".exe") + ".bak"
+}
+
+// swapExecutable renames next to file, keeping file as backup. Where
+// possible the backup is a hard link, so that file is replaced atomically.
+// Windows doesn't allow replacing a running executable, but allows
+// renaming it, so it's moved to the backup instead.
+func swapExecutable(file, next, backup string) error {
+	os.Remove(backup)
+
+	if runtime.GOOS != "windows" && os.Link(file, backup) == nil {
+		return os.Rename(next, file)
+	}
+
+	if err := os.Rename(file, backup); err != nil {
+		return err
+	}
+	if err := os.Rename(next, file); err != nil {
+		os.Rename(backup, file)
+		return err
+	}
+
+	return nil
+}
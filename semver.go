@@ -0,0 +1,223 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidVersion is returned (wrapped) when a version string is not a
+// valid semantic version.
+var ErrInvalidVersion = errors.New("invalid semantic version")
+
+// Version is a parsed semantic version.
+//
+// See also: https://semver.org/spec/v2.0.0.html
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease string // without the leading '-'
+	Build      string // without the leading '+'
+}
+
+// Parse parses a version in the form used by Go modules:
+// vMAJOR.MINOR.PATCH[-PRERELEASE][+BUILD].
+func Parse(v string) (*Version, error) {
+	if !strings.HasPrefix(v, "v") {
+		return nil, fmt.Errorf("%w %q: missing leading v", ErrInvalidVersion, v)
+	}
+
+	rest := v[1:]
+	ver := &Version{}
+
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		ver.Build = rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(ver.Build, false) {
+			return nil, fmt.Errorf("%w %q: bad build metadata", ErrInvalidVersion, v)
+		}
+	}
+
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		ver.Prerelease = rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(ver.Prerelease, true) {
+			return nil, fmt.Errorf("%w %q: bad pre-release", ErrInvalidVersion, v)
+		}
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w %q: want MAJOR.MINOR.PATCH", ErrInvalidVersion, v)
+	}
+
+	for i, p := range []*uint64{&ver.Major, &ver.Minor, &ver.Patch} {
+		if !isNumeric(parts[i]) {
+			return nil, fmt.Errorf("%w %q: bad number %q", ErrInvalidVersion, v, parts[i])
+		}
+		n, err := strconv.ParseUint(parts[i], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidVersion, v, err)
+		}
+		*p = n
+	}
+
+	return ver, nil
+}
+
+// String returns the canonical form of v, with a leading 'v'.
+func (v *Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is less than, equal to
+// or greater than w in semver precedence. Build metadata is ignored.
+func (v *Version) Compare(w *Version) int {
+	if c := compareUint(v.Major, w.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, w.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, w.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, w.Prerelease)
+}
+
+// Diff returns the most significant part in which v and w differ: "major",
+// "minor", "patch", "prerelease", "build", or "" if they are identical.
+func (v *Version) Diff(w *Version) string {
+	switch {
+	case v.Major != w.Major:
+		return "major"
+	case v.Minor != w.Minor:
+		return "minor"
+	case v.Patch != w.Patch:
+		return "patch"
+	case v.Prerelease != w.Prerelease:
+		return "prerelease"
+	case v.Build != w.Build:
+		return "build"
+	}
+	return ""
+}
+
+// Compare compares two version strings like Version.Compare. An invalid
+// version is considered less than any valid one, and two invalid versions
+// are considered equal.
+func Compare(v, w string) int {
+	pv, errV := Parse(v)
+	pw, errW := Parse(w)
+
+	switch {
+	case errV != nil && errW != nil:
+		return 0
+	case errV != nil:
+		return -1
+	case errW != nil:
+		return 1
+	}
+
+	return pv.Compare(pw)
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparePrerelease compares two pre-release strings by semver rules: a
+// version without pre-release is greater, numeric identifiers compare
+// numerically and are less than alphanumeric ones, and a longer list of
+// identifiers wins when all preceding ones are equal.
+func comparePrerelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, y := as[i], bs[i]
+		if x == y {
+			continue
+		}
+
+		xNum, yNum := isNumeric(x), isNumeric(y)
+		switch {
+		case xNum && yNum:
+			// no leading zeros, so the longer one is the bigger one
+			if len(x) != len(y) {
+				return compareUint(uint64(len(x)), uint64(len(y)))
+			}
+			return strings.Compare(x, y)
+		case xNum:
+			return -1
+		case yNum:
+			return 1
+		default:
+			return strings.Compare(x, y)
+		}
+	}
+
+	return compareUint(uint64(len(as)), uint64(len(bs)))
+}
+
+// validIdentifiers reports whether s is a dot-separated list of non-empty
+// [0-9A-Za-z-] identifiers. If prerelease is true, numeric identifiers must
+// not have leading zeros.
+func validIdentifiers(s string, prerelease bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for _, c := range id {
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+				return false
+			}
+		}
+		if prerelease && len(id) > 1 && id[0] == '0' && isDigits(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumeric reports whether s is a number without leading zeros.
+func isNumeric(s string) bool {
+	return isDigits(s) && (len(s) == 1 || s[0] != '0')
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
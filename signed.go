@@ -0,0 +1,162 @@
+package version
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrBadSignature is returned for release metadata which isn't signed
+	// by any of the trusted keys.
+	ErrBadSignature = errors.New("release metadata not signed by a trusted key")
+
+	// ErrMetadataExpired is returned for release metadata past its expiry,
+	// which may be replayed by an attacker to hide newer releases.
+	ErrMetadataExpired = errors.New("release metadata expired")
+)
+
+// SignedRelease is the content of a signed release metadata file, a
+// minimal take on TUF: it advertises the latest release, signed with an
+// ed25519 key whose public half is compiled into the application, so that
+// a MITM on a plain HTTP mirror can't advertise a fake version, and it
+// expires, so that it can't replay an old advertisement forever either.
+type SignedRelease struct {
+	Version   string            `json:"version"`
+	Time      time.Time         `json:"time"`
+	Expires   time.Time         `json:"expires"`
+	Artifacts map[string]string `json:"artifacts,omitempty"` // hex SHA-256 of the binaries by GOOS/GOARCH
+}
+
+// signedMetadata is the file format. The signed payload is kept verbatim,
+// so that signatures don't depend on how JSON is decoded and re-encoded.
+type signedMetadata struct {
+	Signed     json.RawMessage   `json:"signed"`
+	Signatures []metadataSigning `json:"signatures"`
+}
+
+type metadataSigning struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"` // base64
+}
+
+// KeyID returns the id of a public key used in release metadata: the hex
+// encoded first 8 bytes of its SHA-256.
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParsePublicKey parses a base64 encoded ed25519 public key, e.g. a constant
+// compiled into the application.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key: %d bytes, want %d", len(key), ed25519.PublicKeySize)
+	}
+
+	return ed25519.PublicKey(key), nil
+}
+
+// SignRelease returns the metadata file advertising r, signed with key, for
+// release tooling to publish.
+func SignRelease(key ed25519.PrivateKey, r *SignedRelease) ([]byte, error) {
+	if r.Expires.IsZero() {
+		return nil, errors.New("release metadata needs an expiry")
+	}
+
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(signedMetadata{
+		Signed: payload,
+		Signatures: []metadataSigning{{
+			KeyID: KeyID(key.Public().(ed25519.PublicKey)),
+			Sig:   base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+		}},
+	}, "", "  ")
+}
+
+// VerifyRelease verifies release metadata made by SignRelease with the
+// trusted keys. It returns ErrBadSignature unless one of the keys signed
+// it, and ErrMetadataExpired if it has expired.
+func VerifyRelease(data []byte, keys ...ed25519.PublicKey) (*SignedRelease, error) {
+	var m signedMetadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid release metadata: %w", err)
+	}
+
+	if !verifySignatures(m, keys) {
+		return nil, ErrBadSignature
+	}
+
+	var r SignedRelease
+	if err := json.Unmarshal(m.Signed, &r); err != nil {
+		return nil, fmt.Errorf("invalid release metadata: %w", err)
+	}
+
+	if !time.Now().Before(r.Expires) {
+		return nil, fmt.Errorf("%w on %s", ErrMetadataExpired, r.Expires.Format(time.RFC3339))
+	}
+
+	return &r, nil
+}
+
+func verifySignatures(m signedMetadata, keys []ed25519.PublicKey) bool {
+	// the payload is signed compact, but the file may be indented
+	var payload bytes.Buffer
+	if err := json.Compact(&payload, m.Signed); err != nil {
+		return false
+	}
+
+	for _, s := range m.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		for _, key := range keys {
+			if KeyID(key) == s.KeyID && ed25519.Verify(key, payload.Bytes(), sig) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// SignedChecker is an UpdateChecker reading release metadata made by
+// SignRelease, which is safe to serve from untrusted mirrors.
+type SignedChecker struct {
+	URL    string              // of the metadata file
+	Keys   []ed25519.PublicKey // trusted keys, see ParsePublicKey
+	Client *http.Client        // default RemoteOptions.Client
+}
+
+// Latest implements UpdateChecker. The module path is not used, the
+// metadata is the one of the application.
+func (c *SignedChecker) Latest(ctx context.Context, modulePath string) (*ReleaseInfo, error) {
+	data, err := fetchCached(ctx, c.Client, c.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := VerifyRelease(data, c.Keys...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.URL, err)
+	}
+
+	return &ReleaseInfo{Version: r.Version, Time: r.Time}, nil
+}
@@ -0,0 +1,450 @@
+package version
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Link-time version information, set them via -ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/flw-cn/go-version.LinkVersion=v1.2.3"
+//
+// With the default precedence they are only consulted for the fields the
+// toolchain doesn't embed into the binary, e.g. all of them with TinyGo,
+// or the revision of a build outside of its repository. See SetPrecedence.
+var (
+	LinkVersion  string // module version, e.g. v1.2.3
+	LinkPath     string // main package path, e.g. github.com/you/app
+	LinkRevision string // VCS revision id
+	LinkTime     string // commit time in RFC3339 format
+	LinkModified string // "true" if built from a dirty working copy
+)
+
+// sourceFields holds the fields provided by a source of version
+// information, empty if it doesn't provide them.
+type sourceFields struct {
+	Version  string
+	Path     string
+	Revision string
+	Time     string
+	Modified string
+}
+
+var versionFile sourceFields
+
+// SetVersionFile provides the content of a version file, typically embedded
+// into the binary with go:embed, as a fallback source of version information.
+// Like the Link* variables, it is only used for the fields the toolchain
+// doesn't embed with the default precedence, see SetPrecedence.
+//
+// Each non-empty line of content is either a bare version, or a key=value
+// pair where key is one of version, path, revision, time and modified:
+//
+//	v1.2.3
+//	path=github.com/you/app
+//	revision=0123456789ab
+//
+// Lines starting with # are ignored. SetVersionFile should be called during
+// program initialization.
+func SetVersionFile(content string) {
+	var src sourceFields
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			src.Version = line
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "version":
+			src.Version = value
+		case "path":
+			src.Path = value
+		case "revision":
+			src.Revision = value
+		case "time":
+			src.Time = value
+		case "modified":
+			src.Modified = value
+		}
+	}
+
+	versionFile = src
+}
+
+// Source is a source of version information, see SetPrecedence.
+type Source int
+
+// Sources of version information.
+const (
+	SourceNone      Source = iota // no source provides the field
+	SourceBuildInfo               // embedded by the Go toolchain
+	SourceLinker                  // the Link* variables set via -ldflags
+	SourceFile                    // the version file given to SetVersionFile
+	SourceEnv                     // the GO_VERSION_OVERRIDE_* environment variables
+	SourceCI                      // the variables of CI services, e.g. GITHUB_SHA
+)
+
+var sourceNames = []string{
+	SourceNone:      "none",
+	SourceBuildInfo: "buildinfo",
+	SourceLinker:    "ldflags",
+	SourceFile:      "file",
+	SourceEnv:       "env",
+	SourceCI:        "ci",
+}
+
+func (s Source) String() string {
+	if s >= 0 && int(s) < len(sourceNames) {
+		return sourceNames[s]
+	}
+	return "Source(" + strconv.Itoa(int(s)) + ")"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Source) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, the reverse of
+// MarshalText. Unknown names decode as SourceNone.
+func (s *Source) UnmarshalText(text []byte) error {
+	for i, name := range sourceNames {
+		if name == string(text) {
+			*s = Source(i)
+			return nil
+		}
+	}
+
+	*s = SourceNone
+	return nil
+}
+
+// DefaultPrecedence is the precedence of the sources of version
+// information unless set by SetPrecedence. Environment overrides and CI
+// variables aren't consulted by default, as they describe the environment
+// the binary runs in rather than the build.
+var DefaultPrecedence = []Source{SourceBuildInfo, SourceLinker, SourceFile}
+
+// Environment variables of SourceEnv, e.g. to override the version of a
+// binary built without version control information in a container image.
+const (
+	OverrideVersionEnv  = "GO_VERSION_OVERRIDE_VERSION"
+	OverridePathEnv     = "GO_VERSION_OVERRIDE_PATH"
+	OverrideRevisionEnv = "GO_VERSION_OVERRIDE_REVISION"
+	OverrideTimeEnv     = "GO_VERSION_OVERRIDE_TIME"
+	OverrideModifiedEnv = "GO_VERSION_OVERRIDE_MODIFIED"
+)
+
+var (
+	precedenceMu sync.RWMutex
+	precedence   []Source
+)
+
+// SetPrecedence sets the order in which the sources of version information
+// are consulted, field by field: each of the version, path, revision,
+// commit time and modified flag comes from the first source in chain which
+// provides it. Sources left out are never consulted. E.g. to let the
+// environment override everything:
+//
+//	version.SetPrecedence(version.SourceEnv, version.SourceBuildInfo, version.SourceLinker)
+//
+// An empty chain restores DefaultPrecedence. See Sources to find out which
+// source won for each field.
+func SetPrecedence(chain ...Source) {
+	precedenceMu.Lock()
+	defer precedenceMu.Unlock()
+
+	precedence = append([]Source(nil), chain...)
+}
+
+// Precedence returns the order in which the sources of version information
+// are consulted.
+func Precedence() []Source {
+	precedenceMu.RLock()
+	defer precedenceMu.RUnlock()
+
+	if len(precedence) == 0 {
+		return append([]Source(nil), DefaultPrecedence...)
+	}
+	return append([]Source(nil), precedence...)
+}
+
+// SourceValue is the value of a field provided by a source.
+type SourceValue struct {
+	Source Source `json:"source"`
+	Value  string `json:"value"`
+}
+
+// SourceReport tells where a field of the version information came from.
+type SourceReport struct {
+	Field  string `json:"field"`            // version, path, revision, time or modified
+	Value  string `json:"value,omitempty"`  // the value in effect, empty if no source provides it
+	Source Source `json:"source,omitempty"` // the source it came from, or SourceNone
+
+	// Candidates are the values of all the sources in the precedence
+	// chain which provide the field, in order, the first one winning.
+	Candidates []SourceValue `json:"candidates,omitempty"`
+}
+
+// Sources reports, for each field of the version information, which source
+// won and what the other sources provide, to debug where a version comes
+// from. The values are redacted and under the embargo like all other
+// output, see SetRedactor and EmbargoPath; RevealSources reports the
+// genuine ones.
+func Sources() []SourceReport {
+	return sources(exposedFields)
+}
+
+// RevealSources is Sources without redaction and embargo, if token hashes
+// to EmbargoTokenHash, see RevealDetail.
+func RevealSources(token string) ([]SourceReport, error) {
+	if err := checkRevealToken(token); err != nil {
+		return nil, err
+	}
+
+	return sources(func(f sourceFields) sourceFields { return f }), nil
+}
+
+// sources implements Sources, passing the fields of each source through
+// expose.
+func sources(expose func(sourceFields) sourceFields) []SourceReport {
+	info, _ := readToolchainBuildInfo()
+
+	var provided []sourceFields
+	chain := Precedence()
+	for _, src := range chain {
+		provided = append(provided, expose(fieldsOf(src, info)))
+	}
+
+	var reports []SourceReport
+	for _, field := range sourceFieldNames {
+		r := SourceReport{Field: field}
+		for i, fields := range provided {
+			if v := *fields.field(field); v != "" {
+				r.Candidates = append(r.Candidates, SourceValue{Source: chain[i], Value: v})
+			}
+		}
+		if len(r.Candidates) > 0 {
+			r.Value, r.Source = r.Candidates[0].Value, r.Candidates[0].Source
+		}
+		reports = append(reports, r)
+	}
+
+	return reports
+}
+
+// exposedFields returns f as the rest of the package exposes it: it's put
+// through the redaction and the embargo of the build info.
+func exposedFields(f sourceFields) sourceFields {
+	info := &debug.BuildInfo{Path: f.Path, Main: debug.Module{Path: f.Path, Version: f.Version}}
+	for _, s := range []debug.BuildSetting{
+		{Key: "vcs.revision", Value: f.Revision},
+		{Key: "vcs.time", Value: f.Time},
+		{Key: "vcs.modified", Value: f.Modified},
+	} {
+		if s.Value != "" {
+			info.Settings = append(info.Settings, s)
+		}
+	}
+
+	info = applyEmbargo(redactBuildInfo(info))
+
+	exposed := sourceFields{Version: info.Main.Version}
+	if f.Path != "" {
+		exposed.Path = info.Main.Path
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			exposed.Revision = s.Value
+		case "vcs.time":
+			exposed.Time = s.Value
+		case "vcs.modified":
+			exposed.Modified = s.Value
+		}
+	}
+
+	return exposed
+}
+
+var sourceFieldNames = []string{"version", "path", "revision", "time", "modified"}
+
+func (f *sourceFields) field(name string) *string {
+	switch name {
+	case "version":
+		return &f.Version
+	case "path":
+		return &f.Path
+	case "revision":
+		return &f.Revision
+	case "time":
+		return &f.Time
+	}
+	return &f.Modified
+}
+
+// fieldsOf returns the fields provided by src. info is the build info
+// embedded by the toolchain, or nil.
+func fieldsOf(src Source, info *debug.BuildInfo) sourceFields {
+	switch src {
+	case SourceBuildInfo:
+		if info == nil {
+			return sourceFields{}
+		}
+		f := sourceFields{Version: info.Main.Version, Path: info.Main.Path}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				f.Revision = s.Value
+			case "vcs.time":
+				f.Time = s.Value
+			case "vcs.modified":
+				f.Modified = s.Value
+			}
+		}
+		return f
+	case SourceLinker:
+		return sourceFields{
+			Version:  LinkVersion,
+			Path:     LinkPath,
+			Revision: LinkRevision,
+			Time:     LinkTime,
+			Modified: LinkModified,
+		}
+	case SourceFile:
+		return versionFile
+	case SourceEnv:
+		return sourceFields{
+			Version:  os.Getenv(OverrideVersionEnv),
+			Path:     os.Getenv(OverridePathEnv),
+			Revision: os.Getenv(OverrideRevisionEnv),
+			Time:     os.Getenv(OverrideTimeEnv),
+			Modified: os.Getenv(OverrideModifiedEnv),
+		}
+	case SourceCI:
+		return ciFields()
+	}
+
+	return sourceFields{}
+}
+
+// ciFields returns the fields provided by the variables of the CI service
+// the process runs in: GitHub Actions, GitLab CI, Jenkins, Azure Pipelines
+// or any CI setting CI_COMMIT_SHA.
+func ciFields() sourceFields {
+	var f sourceFields
+	for _, env := range []string{"GITHUB_SHA", "CI_COMMIT_SHA", "GIT_COMMIT", "BUILD_SOURCEVERSION"} {
+		if f.Revision = os.Getenv(env); f.Revision != "" {
+			break
+		}
+	}
+
+	if os.Getenv("GITHUB_REF_TYPE") == "tag" {
+		f.Version = os.Getenv("GITHUB_REF_NAME")
+	} else {
+		f.Version = os.Getenv("CI_COMMIT_TAG")
+	}
+	if v, err := ParseGitTag(f.Version); err == nil {
+		f.Version = v.String()
+	} else {
+		f.Version = ""
+	}
+
+	f.Time = os.Getenv("CI_COMMIT_TIMESTAMP")
+
+	return f
+}
+
+// loadBuildInfo returns the build information of the running binary, with
+// each field taken from the first source of the precedence chain which
+// provides it. Without build info embedded by the toolchain, as with
+// TinyGo, it's synthesized if a source provides the version.
+func loadBuildInfo() (*debug.BuildInfo, bool) {
+	info, ok := readToolchainBuildInfo()
+
+	chain := Precedence()
+	consulted := false
+	for _, src := range chain {
+		consulted = consulted || src == SourceBuildInfo
+	}
+	if !consulted {
+		// sources left out are never consulted, not even for the fields
+		// no other source provides
+		info, ok = nil, false
+	}
+
+	var resolved sourceFields
+	winner := make(map[string]Source)
+	for _, src := range chain {
+		fields := fieldsOf(src, info)
+		for _, name := range sourceFieldNames {
+			if v := *fields.field(name); v != "" && *resolved.field(name) == "" {
+				*resolved.field(name) = v
+				winner[name] = src
+			}
+		}
+	}
+
+	if resolved.Version == "" {
+		return nil, false
+	}
+
+	if !ok {
+		info = &debug.BuildInfo{GoVersion: runtime.Version()}
+	} else {
+		copied := *info
+		copied.Settings = append([]debug.BuildSetting(nil), info.Settings...)
+		info = &copied
+	}
+
+	info.Main.Version = resolved.Version
+	if src, ok := winner["path"]; ok && src != SourceBuildInfo {
+		info.Path, info.Main.Path = resolved.Path, resolved.Path
+	}
+
+	for _, s := range []struct{ name, key string }{
+		{"revision", "vcs.revision"},
+		{"time", "vcs.time"},
+		{"modified", "vcs.modified"},
+	} {
+		if src, ok := winner[s.name]; ok && src != SourceBuildInfo {
+			info.Settings = setBuildSetting(info.Settings, s.key, *resolved.field(s.name))
+		}
+	}
+	if _, ok := winner["revision"]; ok && !hasBuildSetting(info.Settings, "vcs") {
+		info.Settings = append(info.Settings, debug.BuildSetting{Key: "vcs", Value: "git"})
+	}
+
+	return info, true
+}
+
+func setBuildSetting(settings []debug.BuildSetting, key, value string) []debug.BuildSetting {
+	for i, s := range settings {
+		if s.Key == key {
+			settings[i].Value = value
+			return settings
+		}
+	}
+	return append(settings, debug.BuildSetting{Key: key, Value: value})
+}
+
+func hasBuildSetting(settings []debug.BuildSetting, key string) bool {
+	for _, s := range settings {
+		if s.Key == key {
+			return true
+		}
+	}
+	return false
+}
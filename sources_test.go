@@ -0,0 +1,58 @@
+package version
+
+import (
+	"testing"
+)
+
+func setLinkVersion(t *testing.T, version string) {
+	old := LinkVersion
+	LinkVersion = version
+	t.Cleanup(func() {
+		LinkVersion = old
+		SetPrecedence()
+	})
+}
+
+func TestLoadBuildInfoWithoutBuildInfoSource(t *testing.T) {
+	if _, ok := readToolchainBuildInfo(); !ok {
+		t.Skip("no build info embedded by the toolchain")
+	}
+	setLinkVersion(t, "v1.2.3")
+
+	SetPrecedence(SourceLinker)
+	info, ok := loadBuildInfo()
+	if !ok {
+		t.Fatal("no build info")
+	}
+
+	if info.Main.Version != "v1.2.3" {
+		t.Errorf("version = %q, want v1.2.3", info.Main.Version)
+	}
+	if info.Path != "" || info.Main.Path != "" {
+		t.Errorf("path = %q, main path = %q, want none from the left out build info", info.Path, info.Main.Path)
+	}
+	if len(info.Settings) > 0 {
+		t.Errorf("settings = %v, want none from the left out build info", info.Settings)
+	}
+}
+
+func TestLoadBuildInfoWithBuildInfoSource(t *testing.T) {
+	toolchain, ok := readToolchainBuildInfo()
+	if !ok {
+		t.Skip("no build info embedded by the toolchain")
+	}
+	setLinkVersion(t, "v1.2.3")
+
+	SetPrecedence(SourceLinker, SourceBuildInfo)
+	info, ok := loadBuildInfo()
+	if !ok {
+		t.Fatal("no build info")
+	}
+
+	if info.Main.Version != "v1.2.3" {
+		t.Errorf("version = %q, want v1.2.3", info.Main.Version)
+	}
+	if info.Path != toolchain.Path {
+		t.Errorf("path = %q, want %q of the build info", info.Path, toolchain.Path)
+	}
+}
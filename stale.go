@@ -0,0 +1,71 @@
+package version
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// staleAfter is the age in nanoseconds after which a build is considered
+// stale, 0 disables the check.
+var staleAfter int64
+
+// SetStaleAfter sets the age after which a build is considered stale, e.g.
+// 180 days. The text output of stale builds then carries a warning like
+// "This build is 7 months old, please consider upgrading.", which nudges
+// users of long-forgotten binaries to upgrade. Zero, the default, disables
+// the warning.
+func SetStaleAfter(age time.Duration) {
+	atomic.StoreInt64(&staleAfter, int64(age))
+}
+
+// Age returns how long ago the build's last commit was made. ok is false if
+// the build carries no commit time, e.g. a release installed with
+// `go install`.
+func (d Detail) Age() (age time.Duration, ok bool) {
+	t := d.LastCommit
+	if t.IsZero() {
+		t = d.ModVersion.Time
+	}
+	if t.IsZero() {
+		return 0, false
+	}
+
+	return time.Since(t), true
+}
+
+// StaleWarning returns a warning if the build is older than the age set by
+// SetStaleAfter, or an empty string.
+func (d Detail) StaleWarning() string {
+	limit := time.Duration(atomic.LoadInt64(&staleAfter))
+	if limit <= 0 {
+		return ""
+	}
+
+	age, ok := d.Age()
+	if !ok || age < limit {
+		return ""
+	}
+
+	return fmt.Sprintf("This build is %s old, please consider upgrading.", humanAge(age))
+}
+
+// humanAge formats age in the largest sensible unit, e.g. "3 days",
+// "7 months" or "2 years".
+func humanAge(age time.Duration) string {
+	const day = 24 * time.Hour
+
+	n, unit := int(age/day), "day"
+	switch {
+	case age >= 2*365*day:
+		n, unit = int(age/(365*day)), "year"
+	case age >= 60*day:
+		n, unit = int(age/(30*day)), "month"
+	}
+
+	if n != 1 {
+		unit += "s"
+	}
+
+	return fmt.Sprintf("%d %s", n, unit)
+}
@@ -0,0 +1,107 @@
+package version
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// StampError is returned by VerifyStamp and VerifyStampOutput when a binary
+// doesn't carry the version being released.
+type StampError struct {
+	File    string
+	Tag     string // the expected version
+	Version string // the version found, empty if none
+	Source  string // where it was found, e.g. "build info"
+}
+
+func (e *StampError) Error() string {
+	if e.Version == "" {
+		return fmt.Sprintf("%s: no version found in %s, want %s", e.File, e.Source, e.Tag)
+	}
+
+	return fmt.Sprintf("%s: %s has version %s, want %s", e.File, e.Source, e.Version, e.Tag)
+}
+
+// VerifyStamp checks that the Go binary at file carries the version of tag,
+// the tag being released, e.g. as a release pipeline step right after the
+// build. Both the module version and versions injected with -ldflags -X
+// into variables named like "version" must equal the tag; a "+dirty" build
+// doesn't. Tags of the shapes accepted by ParseGitTag, like sub/v1.2.3 or
+// 1.2.3, are accepted.
+func VerifyStamp(file, tag string) error {
+	tag = normalizeTag(tag)
+
+	report, err := Inspect(file)
+	if err != nil {
+		return err
+	}
+	if report.Confidence < ConfidenceHigh {
+		return fmt.Errorf("%s: no intact build info found", file)
+	}
+
+	if v := report.Info.Main.Version; v != tag {
+		return &StampError{File: file, Tag: tag, Version: v, Source: "build info"}
+	}
+
+	for _, v := range injectedVersions(report.Info.Settings) {
+		if v != tag {
+			return &StampError{File: file, Tag: tag, Version: v, Source: "-ldflags -X"}
+		}
+	}
+
+	return nil
+}
+
+// VerifyStampOutput is like VerifyStamp, but runs the binary at file with
+// args, default "--version", and checks the first version in its output,
+// which catches version strings the build info doesn't show, e.g. ones
+// read from generated files.
+func VerifyStampOutput(ctx context.Context, file, tag string, args ...string) error {
+	tag = normalizeTag(tag)
+	if len(args) == 0 {
+		args = []string{"--version"}
+	}
+
+	out, err := exec.CommandContext(ctx, file, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", file, strings.Join(args, " "), err)
+	}
+
+	source := "the output of " + strings.Join(append([]string{"the binary"}, args...), " ")
+
+	found, err := ExtractVersions(bytes.NewReader(out))
+	if err != nil {
+		return err
+	}
+	for _, f := range found {
+		if f.Version == nil {
+			continue
+		}
+		if v := f.Version.String(); v != tag {
+			return &StampError{File: file, Tag: tag, Version: v, Source: source}
+		}
+		return nil
+	}
+
+	return &StampError{File: file, Tag: tag, Source: source}
+}
+
+// normalizeTag turns a release tag into the module version it stands for,
+// see ParseGitTag.
+func normalizeTag(tag string) string {
+	if v, err := ParseGitTag(tag); err == nil {
+		return v.String()
+	}
+
+	if i := strings.LastIndex(tag, "/"); i >= 0 {
+		tag = tag[i+1:]
+	}
+	if !strings.HasPrefix(tag, "v") {
+		tag = "v" + tag
+	}
+
+	return tag
+}
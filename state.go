@@ -0,0 +1,272 @@
+package version
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	stateMu  sync.RWMutex
+	stateDir string
+)
+
+// SetStateDir sets the directory where state such as the last-run version is
+// persisted. By default it's a directory named after the import path of the
+// main package, e.g. github.com/you/app/cmd/app, in $XDG_STATE_HOME
+// (~/.local/state on Unix) or os.UserConfigDir() on macOS and Windows, so
+// that the state stays put when the binary is renamed.
+func SetStateDir(dir string) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	stateDir = dir
+}
+
+// StateDir returns the directory where state is persisted.
+func StateDir() (string, error) {
+	stateMu.RLock()
+	dir := stateDir
+	stateMu.RUnlock()
+
+	if dir != "" {
+		return dir, nil
+	}
+
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		switch runtime.GOOS {
+		case "darwin", "ios", "windows", "plan9":
+			var err error
+			if base, err = os.UserConfigDir(); err != nil {
+				return "", err
+			}
+		default:
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			base = filepath.Join(home, ".local", "state")
+		}
+	}
+
+	// the genuine path of the main package, not the one displayed, which
+	// SetDisplayModule and EmbargoPath change
+	info, ok := readToolchainBuildInfo()
+	if !ok {
+		info, ok = loadBuildInfo()
+	}
+
+	name := "go-version"
+	if ok && info.Path != "" {
+		// escaped like in the module cache, for case-insensitive file
+		// systems
+		if escaped, err := EscapePath(info.Path); err == nil {
+			name = filepath.FromSlash(escaped)
+		}
+	}
+
+	return filepath.Join(base, name), nil
+}
+
+// RunRecord describes a run of the application.
+type RunRecord struct {
+	Version  string    `json:"version"`
+	Revision string    `json:"revision,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+const lastRunFile = "last-run.json"
+
+// currentRun returns the RunRecord of the running binary.
+func currentRun() (*RunRecord, error) {
+	d, err := GetDetail()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RunRecord{Version: d.AppVersion, Time: time.Now()}
+	if d.Revision != "unknown" {
+		r.Revision = d.Revision
+	}
+
+	return r, nil
+}
+
+// ReadLastRun returns the record persisted by the last RecordRun, or nil if
+// there is none.
+func ReadLastRun() (*RunRecord, error) {
+	var r RunRecord
+	if err := readState(lastRunFile, &r); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// RecordRun persists the version of the running binary as the last-run
+// version.
+func RecordRun() error {
+	r, err := currentRun()
+	if err != nil {
+		return err
+	}
+
+	return writeState(lastRunFile, r)
+}
+
+// CheckDowngrade reports whether the running binary is older than the last
+// one that ran, which matters to tools with forward-only on-disk formats.
+// It also returns the last run, nil if there was none.
+//
+// Unless a downgrade is detected, the running version is recorded as the
+// last-run version, so that a refused downgrade is detected again on the next
+// attempt. Versions which aren't valid semver are never considered a
+// downgrade.
+func CheckDowngrade() (downgraded bool, last *RunRecord, err error) {
+	last, err = ReadLastRun()
+	if err != nil {
+		return false, nil, err
+	}
+
+	cur, err := currentRun()
+	if err != nil {
+		return false, last, err
+	}
+
+	if last != nil {
+		pv, err1 := Parse(last.Version)
+		cv, err2 := Parse(cur.Version)
+		if err1 == nil && err2 == nil && cv.Compare(pv) < 0 {
+			return true, last, nil
+		}
+	}
+
+	return false, last, writeState(lastRunFile, cur)
+}
+
+// readState decodes the JSON state file name.
+func readState(name string, v interface{}) error {
+	dir, err := StateDir()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// writeState atomically replaces the JSON state file name.
+func writeState(name string, v interface{}) error {
+	dir, err := StateDir()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(dir, name), append(data, '\n'))
+}
+
+const (
+	lockTimeout = 5 * time.Second  // how long lockState waits for a lock
+	lockStale   = 30 * time.Second // age of a lock left behind by a crash
+)
+
+// lockState takes the lock of the state file name across processes, for a
+// read-modify-write of it, by creating name.lock exclusively. It waits for
+// up to lockTimeout for other processes to release it, and breaks locks
+// left behind by a process that crashed while holding it: those of a
+// process no longer running, and any older than lockStale. The returned
+// function releases the lock.
+func lockState(name string) (func(), error) {
+	dir, err := StateDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	lock := filepath.Join(dir, name+".lock")
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			fmt.Fprintln(f, os.Getpid())
+			f.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if fi, err := os.Stat(lock); err == nil && (time.Since(fi.ModTime()) > lockStale || lockHolderGone(lock)) {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("state file %s is locked by another process", name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// lockHolderGone reports whether the process whose PID is in lock is no
+// longer running. A lock without a PID yet is being taken.
+func lockHolderGone(lock string) bool {
+	data, err := os.ReadFile(lock)
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return false
+	}
+
+	return !processAlive(pid)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to file, so
+// readers never see partial content. Missing directories are created.
+func writeFileAtomic(file string, data []byte) error {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+
+	return err
+}
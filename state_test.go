@@ -0,0 +1,29 @@
+package version
+
+import (
+	"testing"
+)
+
+func TestStateDirIgnoresDisplayedPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	want, err := StateDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetDisplayModule("https://example.com/app")
+	EmbargoPath = "oem.example/router"
+	t.Cleanup(func() {
+		SetDisplayModule("")
+		EmbargoPath = ""
+	})
+
+	got, err := StateDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("StateDir() = %q with a displayed path and an embargo, want %q", got, want)
+	}
+}
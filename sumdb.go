@@ -0,0 +1,223 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+)
+
+// ErrSumDBOff is returned when the checksum database is disabled by
+// GOSUMDB=off.
+var ErrSumDBOff = errors.New("checksum database disabled by GOSUMDB=off")
+
+// Results of the verification of a module sum by SumDB.Verify.
+const (
+	SumOK        = "ok"
+	SumMismatch  = "mismatch"  // the recorded sum differs from the published one
+	SumUnchecked = "unchecked" // see SumCheck.Reason
+)
+
+// SumDB is a client of the Go checksum database, which publishes the h1
+// sums of all public module versions. It follows cmd/go for GOSUMDB,
+// GONOSUMDB and GOPRIVATE, and looks the sums up through the module proxies
+// in GOPROXY which mirror the database before asking it directly.
+//
+// SumDB compares sums only; unlike cmd/go it doesn't verify the signed tree
+// of the database, so it trusts the TLS connection to the proxy or the
+// database instead.
+type SumDB struct {
+	Name       string       // database name, default "sum.golang.org"
+	URL        string       // default https://NAME
+	Proxy      string       // GOPROXY list, default "https://proxy.golang.org,direct"
+	NoSumCheck string       // GONOSUMDB patterns
+	Client     *http.Client // default RemoteOptions.Client
+}
+
+// NewSumDB returns a SumDB configured from the environment.
+func NewSumDB() *SumDB {
+	db := &SumDB{Proxy: os.Getenv("GOPROXY")}
+
+	// GOSUMDB is "off", "NAME", "NAME+KEY" or "NAME+KEY URL"
+	fields := strings.Fields(os.Getenv("GOSUMDB"))
+	if len(fields) > 0 {
+		db.Name, _, _ = strings.Cut(fields[0], "+")
+	}
+	if len(fields) > 1 {
+		db.URL = fields[1]
+	}
+
+	noSumCheck, ok := os.LookupEnv("GONOSUMDB")
+	if !ok {
+		noSumCheck = os.Getenv("GOPRIVATE")
+	}
+	db.NoSumCheck = noSumCheck
+
+	return db
+}
+
+// Lookup returns the published h1 sum of the module zip of modulePath at
+// version.
+func (db *SumDB) Lookup(ctx context.Context, modulePath, version string) (string, error) {
+	name := db.Name
+	if name == "" {
+		name = "sum.golang.org"
+	}
+	if name == "off" {
+		return "", ErrSumDBOff
+	}
+
+	escapedPath, err := EscapePath(modulePath)
+	if err != nil {
+		return "", err
+	}
+	escapedVersion, err := EscapePath(version)
+	if err != nil {
+		return "", err
+	}
+	suffix := "/lookup/" + escapedPath + "@" + escapedVersion
+
+	direct := db.URL
+	if direct == "" {
+		direct = "https://" + name
+	}
+
+	var urls []string
+	proxies := db.Proxy
+	if proxies == "" {
+		proxies = "https://proxy.golang.org,direct"
+	}
+	for _, proxy := range strings.FieldsFunc(proxies, func(r rune) bool { return r == ',' || r == '|' }) {
+		switch proxy = strings.TrimSpace(proxy); proxy {
+		case "off", "direct":
+		default:
+			urls = append(urls, strings.TrimSuffix(proxy, "/")+"/sumdb/"+name+suffix)
+		}
+	}
+	urls = append(urls, strings.TrimSuffix(direct, "/")+suffix)
+
+	var data []byte
+	for _, url := range urls {
+		if data, err = fetchCached(ctx, db.Client, url, nil); err == nil || !isNotFound(err) {
+			break
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// the records come first, one per line, then the signed tree
+	for _, line := range strings.Split(string(data), "\n") {
+		f := strings.Fields(line)
+		if len(f) == 3 && f[0] == modulePath && f[1] == version {
+			return f[2], nil
+		}
+	}
+
+	return "", fmt.Errorf("no sum of %s@%s in the response of %s", modulePath, version, name)
+}
+
+// SumCheck is the verification of the sum of one module recorded in a
+// binary.
+type SumCheck struct {
+	Path      string // module path, of the replacement if replaced
+	Version   string
+	Recorded  string // h1 sum recorded in the binary
+	Published string // h1 sum published in the checksum database
+	Status    string // SumOK, SumMismatch or SumUnchecked
+	Reason    string // why the sum is unchecked
+}
+
+// SumMismatchError is returned by SumDB.Verify if any recorded sum differs
+// from the published one, a sign that the binary was built from modified
+// sources.
+type SumMismatchError struct {
+	Checks []SumCheck // the mismatches
+}
+
+func (e *SumMismatchError) Error() string {
+	msgs := make([]string, len(e.Checks))
+	for i, c := range e.Checks {
+		msgs[i] = fmt.Sprintf("%s@%s has sum %s, the checksum database has %s",
+			c.Path, c.Version, c.Recorded, c.Published)
+	}
+
+	return "module sums don't match: " + strings.Join(msgs, "; ")
+}
+
+// Verify compares the sums recorded in info, such as the build info of a
+// BinaryReport, with the published ones. It returns the result of every
+// module, the main module first, and a *SumMismatchError if any differs.
+// Modules without a recorded sum, such as a main module built in its own
+// directory or dependencies replaced by local directories, modules excluded
+// by NoSumCheck and modules whose lookup fails are unchecked.
+func (db *SumDB) Verify(ctx context.Context, info *debug.BuildInfo) ([]SumCheck, error) {
+	var (
+		checks     []SumCheck
+		mismatches []SumCheck
+	)
+	for _, m := range builtModules(info) {
+		c := SumCheck{Path: m.Path, Version: m.Version, Recorded: m.Sum, Status: SumUnchecked}
+
+		switch {
+		case m.Sum == "":
+			c.Reason = "no sum recorded"
+		case matchPrefixPatterns(db.NoSumCheck, m.Path):
+			c.Reason = "excluded from the checksum database"
+		default:
+			published, err := db.Lookup(ctx, m.Path, m.Version)
+			switch {
+			case errors.Is(err, ErrSumDBOff) || errors.Is(err, ErrOffline):
+				return nil, err
+			case err != nil && ctx.Err() != nil:
+				return nil, ctx.Err()
+			case err != nil:
+				c.Reason = err.Error()
+			case published == m.Sum:
+				c.Published, c.Status = published, SumOK
+			default:
+				c.Published, c.Status = published, SumMismatch
+				mismatches = append(mismatches, c)
+			}
+		}
+
+		checks = append(checks, c)
+	}
+
+	if len(mismatches) > 0 {
+		return checks, &SumMismatchError{Checks: mismatches}
+	}
+
+	return checks, nil
+}
+
+// Sums returns the h1 sums recorded in the binary, keyed by module@version,
+// of the main module, if it was built from a module version, and of the
+// dependencies, after replacements.
+func (r *BinaryReport) Sums() map[string]string {
+	sums := make(map[string]string)
+	for _, m := range builtModules(r.Info) {
+		if m.Sum != "" {
+			sums[m.Path+"@"+m.Version] = m.Sum
+		}
+	}
+
+	return sums
+}
+
+// builtModules returns the main module and the dependencies of info, the
+// replacements in place of replaced ones.
+func builtModules(info *debug.BuildInfo) []*debug.Module {
+	mods := []*debug.Module{&info.Main}
+	for _, dep := range info.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		mods = append(mods, dep)
+	}
+
+	return mods
+}
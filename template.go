@@ -0,0 +1,292 @@
+package version
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// TemplateLimits bounds the execution of the templates of this package,
+// such as the brief and detail templates, which may come from user
+// configuration files. A template exceeding them fails with an error
+// instead of hanging or exhausting memory.
+//
+// Go can't stop a running template, so one exceeding Timeout is abandoned:
+// it's stopped at its next write, but one that doesn't write anymore, e.g.
+// in a {{range}} over a huge sequence with an empty body, or blocked in a
+// method it calls, keeps running in its goroutine until it ends. Templates
+// from untrusted sources should be validated, see TextFormatter.Validate,
+// and kept away from such constructs.
+type TemplateLimits struct {
+	Timeout   time.Duration // 0 means one second, negative means none
+	MaxOutput int           // in bytes, 0 means 1 MiB, negative means none
+}
+
+var (
+	templateMu     sync.RWMutex
+	templateLimits TemplateLimits
+)
+
+// SetTemplateLimits sets the limits of template execution.
+func SetTemplateLimits(limits TemplateLimits) {
+	templateMu.Lock()
+	defer templateMu.Unlock()
+
+	templateLimits = limits
+}
+
+func currentTemplateLimits() TemplateLimits {
+	templateMu.RLock()
+	defer templateMu.RUnlock()
+
+	limits := templateLimits
+	if limits.Timeout == 0 {
+		limits.Timeout = time.Second
+	}
+	if limits.MaxOutput == 0 {
+		limits.MaxOutput = 1 << 20
+	}
+
+	return limits
+}
+
+// errTemplateAborted is returned to a template still executing after its
+// timeout, to stop it at its next write.
+var errTemplateAborted = errors.New("template execution aborted")
+
+// limitedBuffer is a bytes.Buffer failing writes beyond max bytes, or
+// after the execution has been aborted.
+type limitedBuffer struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	max     int
+	aborted bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.aborted {
+		return 0, errTemplateAborted
+	}
+	if b.max >= 0 && b.buf.Len()+len(p) > b.max {
+		return 0, fmt.Errorf("template output exceeds %d bytes", b.max)
+	}
+
+	return b.buf.Write(p)
+}
+
+// executeTemplate executes tmpl with data within the TemplateLimits, and
+// writes the output to w if it succeeds. Panics of the methods called by
+// the template are returned as errors. After a timeout, the goroutine
+// executing the template lives on until its next write, or until it ends if
+// it doesn't write anymore, see TemplateLimits.
+func executeTemplate(tmpl *template.Template, data interface{}, w io.Writer) error {
+	limits := currentTemplateLimits()
+	out := &limitedBuffer{max: limits.MaxOutput}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("template: %s: panic: %v", tmpl.Name(), r)
+			}
+		}()
+		done <- tmpl.Execute(out, data)
+	}()
+
+	var timeout <-chan time.Time
+	if limits.Timeout > 0 {
+		timer := time.NewTimer(limits.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+	case <-timeout:
+		out.mu.Lock()
+		out.aborted = true
+		out.mu.Unlock()
+		return fmt.Errorf("template: %s: execution exceeded %s", tmpl.Name(), limits.Timeout)
+	}
+
+	_, err := w.Write(out.buf.Bytes())
+	return err
+}
+
+// parseTemplate parses text as a template named name, and checks that the
+// fields and methods it references exist on data, so that a typo in a
+// template from a configuration file is reported with its line and column
+// when it's loaded, not when it's first executed, if ever.
+func parseTemplate(name, text string, data interface{}) (*template.Template, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	root := reflect.TypeOf(data)
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		c := &fieldChecker{tree: t.Tree, root: root}
+		// the dot of templates invoked by {{template}} is unknown
+		dot := root
+		if t.Name() != name {
+			dot = nil
+		}
+		c.walk(t.Tree.Root, dot)
+		if len(c.errs) > 0 {
+			return nil, errors.New(strings.Join(c.errs, "\n"))
+		}
+	}
+
+	return tmpl, nil
+}
+
+// fieldChecker checks the field references of a template against the type
+// of its data. Wherever the type of dot can't be determined statically, as
+// for results of functions or variables, references aren't checked.
+type fieldChecker struct {
+	tree *parse.Tree
+	root reflect.Type
+	errs []string
+}
+
+func (c *fieldChecker) walk(node parse.Node, dot reflect.Type) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		c.pipe(n.Pipe, dot)
+	case *parse.IfNode:
+		c.pipe(n.Pipe, dot)
+		c.walk(n.List, dot)
+		c.walk(n.ElseList, dot)
+	case *parse.WithNode:
+		t := c.pipe(n.Pipe, dot)
+		c.walk(n.List, t)
+		c.walk(n.ElseList, dot)
+	case *parse.RangeNode:
+		t := c.pipe(n.Pipe, dot)
+		var elem reflect.Type
+		if t = indirectType(t); t != nil {
+			switch t.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				elem = t.Elem()
+			}
+		}
+		c.walk(n.List, elem)
+		c.walk(n.ElseList, dot)
+	case *parse.TemplateNode:
+		c.pipe(n.Pipe, dot)
+	}
+}
+
+// pipe checks the commands of a pipeline and returns the type of its
+// result, or nil if unknown.
+func (c *fieldChecker) pipe(p *parse.PipeNode, dot reflect.Type) reflect.Type {
+	if p == nil {
+		return nil
+	}
+
+	var result reflect.Type
+	for _, cmd := range p.Cmds {
+		result = nil
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				result = c.fields(a, dot, a.Ident)
+			case *parse.VariableNode:
+				if a.Ident[0] == "$" && len(a.Ident) > 1 {
+					result = c.fields(a, c.root, a.Ident[1:])
+				}
+			case *parse.DotNode:
+				result = dot
+			case *parse.PipeNode:
+				c.pipe(a, dot)
+			}
+		}
+		if len(cmd.Args) > 1 {
+			result = nil // a function or method call
+		}
+	}
+
+	if len(p.Decl) > 0 {
+		return nil
+	}
+
+	return result
+}
+
+// fields resolves a chain of field or method names on t.
+func (c *fieldChecker) fields(node parse.Node, t reflect.Type, idents []string) reflect.Type {
+	for _, ident := range idents {
+		if t == nil {
+			return nil
+		}
+
+		// only the method set of t itself, as data is passed by value and
+		// the methods with pointer receivers can't be called on it
+		if m, ok := t.MethodByName(ident); ok {
+			t = methodResult(m.Type)
+			continue
+		}
+
+		switch it := indirectType(t); {
+		case it == nil:
+			return nil
+		case it.Kind() == reflect.Struct:
+			f, ok := it.FieldByName(ident)
+			if !ok || f.PkgPath != "" {
+				loc, _ := c.tree.ErrorContext(node)
+				c.errs = append(c.errs, fmt.Sprintf("template: %s: unknown field .%s in %s", loc, ident, it))
+				return nil
+			}
+			t = f.Type
+		case it.Kind() == reflect.Map:
+			t = it.Elem()
+		default:
+			return nil
+		}
+	}
+
+	return t
+}
+
+// indirectType dereferences pointer types, and returns nil for interfaces,
+// whose dynamic type is unknown.
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != nil && t.Kind() == reflect.Interface {
+		return nil
+	}
+
+	return t
+}
+
+func methodResult(m reflect.Type) reflect.Type {
+	if m.NumOut() == 0 {
+		return nil
+	}
+	return m.Out(0)
+}
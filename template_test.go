@@ -0,0 +1,22 @@
+package version
+
+import (
+	"io"
+	"testing"
+)
+
+func TestParseTemplateMethodSet(t *testing.T) {
+	// IsRelease has a pointer receiver, so it can't be called on a Detail
+	// passed by value
+	if _, err := parseTemplate("detail", "{{.IsRelease}}", Detail{}); err == nil {
+		t.Error("accepted a method with a pointer receiver on a value")
+	}
+
+	tmpl, err := parseTemplate("detail", "{{.IsRelease}}", &Detail{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := executeTemplate(tmpl, &Detail{}, io.Discard); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly) || tinygo
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly tinygo
+
+package version
+
+import (
+	"os"
+)
+
+// fileWidth can't query terminals on this platform (notably Windows, whose
+// console API is not in package syscall, and WebAssembly, which has no
+// terminal at all), so only COLUMNS or an explicit width enable wrapping.
+func fileWidth(f *os.File) int {
+	return 0
+}
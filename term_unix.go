@@ -0,0 +1,27 @@
+//go:build (linux || darwin || freebsd || netbsd || openbsd || dragonfly) && !tinygo
+// +build linux darwin freebsd netbsd openbsd dragonfly
+// +build !tinygo
+
+package version
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// fileWidth returns the number of columns of the terminal f is attached to,
+// or 0 if f is not a terminal.
+func fileWidth(f *os.File) int {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(),
+		uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+
+	return int(ws.Col)
+}
@@ -0,0 +1,163 @@
+package version
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// UpdateChecker finds the latest release of a module. ProxyClient, the
+// forge checkers GitHubChecker, GitLabChecker and GiteaChecker, AtomChecker,
+// FeedChecker and SignedChecker implement it.
+type UpdateChecker interface {
+	Latest(ctx context.Context, modulePath string) (*ReleaseInfo, error)
+}
+
+// UpdateInfo is the result of an update check.
+type UpdateInfo struct {
+	Module    string       // main module path
+	Current   string       // version of the running binary
+	Latest    *ReleaseInfo // the latest published version
+	Available bool         // Latest is newer than Current
+}
+
+// CheckUpdate looks up the latest version of the main module of the running
+// binary through the module proxy configured in the environment, see
+// ProxyClient. The bundle set by SetBundle answers if the lookup fails or
+// in offline mode. Development builds never have an update available.
+func CheckUpdate(ctx context.Context) (*UpdateInfo, error) {
+	return CheckUpdateWith(ctx, NewProxyClient())
+}
+
+// CheckUpdateWith is like CheckUpdate but looks up the latest version with
+// checker. A nil checker means the bundle set by SetBundle, if it contains
+// the module, else a ProxyClient.
+func CheckUpdateWith(ctx context.Context, checker UpdateChecker) (*UpdateInfo, error) {
+	u, err := checkUpdate(ctx, checker)
+	recordUpdate(u, err)
+	observeUpdate(ctx, u, err)
+
+	return u, err
+}
+
+func checkUpdate(ctx context.Context, checker UpdateChecker) (*UpdateInfo, error) {
+	info, ok := readBuildInfo()
+	if !ok {
+		return nil, ErrNoBuildInfo
+	}
+
+	// the bundle is a snapshot, so a live lookup is preferred
+	bundled, inBundle := bundleRelease(info.Main.Path)
+	if inBundle && (checker == nil || IsOffline()) {
+		return newUpdateInfo(info.Main.Path, info.Main.Version, bundled), nil
+	}
+
+	if IsOffline() {
+		return nil, ErrOffline
+	}
+	if checker == nil {
+		checker = NewProxyClient()
+	}
+
+	latest, err := latestOnce(ctx, checker, info.Main.Path)
+	if err != nil {
+		if inBundle {
+			return newUpdateInfo(info.Main.Path, info.Main.Version, bundled), nil
+		}
+		return nil, err
+	}
+
+	return newUpdateInfo(info.Main.Path, info.Main.Version, latest), nil
+}
+
+func newUpdateInfo(module, current string, latest *ReleaseInfo) *UpdateInfo {
+	u := &UpdateInfo{
+		Module:  module,
+		Current: current,
+		Latest:  latest,
+	}
+
+	if _, err := Parse(current); err == nil {
+		u.Available = Compare(latest.Version, current) > 0
+	}
+
+	return u
+}
+
+// UpdateResult is the structured outcome of an update check, passed to the
+// observer set by SetUpdateObserver.
+type UpdateResult struct {
+	Time      time.Time     `json:"time"`
+	Module    string        `json:"module"`
+	Current   string        `json:"current"`
+	Latest    string        `json:"latest,omitempty"`
+	Available bool          `json:"available"`
+	Age       time.Duration `json:"age,omitempty"` // age of the running build, if known
+	Channel   string        `json:"channel"`       // "stable", "prerelease", "nightly" or "devel"
+	Err       error         `json:"-"`             // why the check failed
+}
+
+var (
+	updateObserverMu sync.RWMutex
+	updateObserver   func(ctx context.Context, r *UpdateResult)
+)
+
+// SetUpdateObserver sets fn to be called with the result of every update
+// check made by CheckUpdate and CheckUpdateWith, failed ones included, so
+// that services can report outdated fleet members through their logging or
+// alerting pipelines instead of printing. A nil fn removes the observer.
+func SetUpdateObserver(fn func(ctx context.Context, r *UpdateResult)) {
+	updateObserverMu.Lock()
+	defer updateObserverMu.Unlock()
+
+	updateObserver = fn
+}
+
+func observeUpdate(ctx context.Context, u *UpdateInfo, err error) {
+	updateObserverMu.RLock()
+	fn := updateObserver
+	updateObserverMu.RUnlock()
+
+	if fn == nil {
+		return
+	}
+
+	r := &UpdateResult{Time: time.Now(), Err: err}
+	if d, derr := GetDetail(); derr == nil {
+		if d.raw != nil {
+			r.Module = d.raw.Main.Path
+		}
+		r.Current = d.AppVersion
+		r.Age, _ = d.Age()
+		r.Channel = channelOf(d)
+	}
+	if u != nil {
+		r.Module = u.Module
+		r.Available = u.Available
+		if u.Latest != nil {
+			r.Latest = u.Latest.Version
+		}
+	}
+
+	fn(ctx, r)
+}
+
+// channelOf returns the release channel the running build belongs to.
+func channelOf(d *Detail) string {
+	if d.Type == Nightly {
+		return "nightly"
+	}
+
+	v, err := Parse(d.AppVersion)
+	switch {
+	case err != nil || d.Type == ErrorVersion || d.Type == Devel:
+		return "devel"
+	case d.Type != Release && d.Type != PreRelease:
+		// pseudo versions look like pre-releases, but are development builds
+		return "devel"
+	case v.Prerelease != "":
+		return "prerelease"
+	}
+
+	return "stable"
+}
@@ -12,30 +12,30 @@
 // and semantic version.
 //
 // See also:
-//  * Go Modules Reference: https://go.dev/ref/mod
-//  * Semantic Versioning 2.0.0: https://semver.org/spec/v2.0.0.html
-//
+//   - Go Modules Reference: https://go.dev/ref/mod
+//   - Semantic Versioning 2.0.0: https://semver.org/spec/v2.0.0.html
 package version
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"html/template"
 	"io"
-	"path/filepath"
+	"os"
 	"runtime/debug"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// VersionType indicate the type of Version, there are six valid VersionTypes:
-//   * Devel
-//   * Release
-//   * PreRelease
-//   * PseudoBaseNoTag
-//   * PseudoBaseRelease
-//   * PseudoBasePreRelease
-//
+// VersionType indicate the type of Version, there are seven valid VersionTypes:
+//   - Devel
+//   - Release
+//   - PreRelease
+//   - PseudoBaseNoTag
+//   - PseudoBaseRelease
+//   - PseudoBasePreRelease
+//   - Nightly
 type VersionType int
 
 const (
@@ -46,122 +46,254 @@ const (
 	PseudoBaseRelease                       // built on several patches after a release tag
 	PseudoBasePreRelease                    // built on several patches after a pre-release tag
 	ErrorVersion                            // some errors have occurred
+	Nightly                                 // a nightly build named by NightlyNaming
 )
 
+var versionTypeNames = [...]string{
+	Devel:                "devel",
+	Release:              "release",
+	PreRelease:           "pre-release",
+	PseudoBaseNoTag:      "pseudo-base-no-tag",
+	PseudoBaseRelease:    "pseudo-base-release",
+	PseudoBasePreRelease: "pseudo-base-pre-release",
+	ErrorVersion:         "error",
+	Nightly:              "nightly",
+}
+
+func (t VersionType) String() string {
+	if t >= 0 && int(t) < len(versionTypeNames) {
+		return versionTypeNames[t]
+	}
+	return "VersionType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// MarshalText implements encoding.TextMarshaler, so that the type shows as
+// a name in JSON and other encodings.
+func (t VersionType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, the reverse of
+// MarshalText. Unknown names decode as ErrorVersion.
+func (t *VersionType) UnmarshalText(text []byte) error {
+	for i, name := range versionTypeNames {
+		if name == string(text) {
+			*t = VersionType(i)
+			return nil
+		}
+	}
+
+	*t = ErrorVersion
+	return nil
+}
+
 // ModVersion represents the information retrieved from debug.Module.Version.
 type ModVersion struct {
-	Type     VersionType
-	Tag      string
-	CommitID string
-	Time     time.Time
+	Type     VersionType `json:"type"`
+	Tag      string      `json:"tag,omitempty"`
+	CommitID string      `json:"commitId,omitempty"`
+	Time     time.Time   `json:"time"`
+
+	// Time in other clock formats for tools that don't parse Go's time
+	// format well, set by GetDetail: Unix seconds and RFC 3339 in UTC.
+	TimeUnix int64  `json:"timeUnix,omitempty"`
+	TimeUTC  string `json:"timeUTC,omitempty"`
 }
 
 // VcsInfo represents the information retrieved from debug.BuildSetting.
 type VcsInfo struct {
-	VCS        string
-	Revision   string
-	IsDirty    bool
-	LastCommit time.Time
+	VCS        string    `json:"vcs"`
+	Revision   string    `json:"revision"`
+	IsDirty    bool      `json:"dirty"`
+	LastCommit time.Time `json:"lastCommit"`
+
+	// LastCommit in other clock formats, see ModVersion.TimeUnix.
+	LastCommitUnix int64  `json:"lastCommitUnix,omitempty"`
+	LastCommitUTC  string `json:"lastCommitUTC,omitempty"`
 }
 
 // Brief provides the field to render a brief version line.
 type Brief struct {
-	AppName    string
-	ModulePath string
-	AppVersion string
-	GoVersion  string
+	AppName     string `json:"appName"`
+	Description string `json:"description,omitempty"`
+	ModulePath  string `json:"modulePath"`
+	AppVersion  string `json:"appVersion"`
+	GoVersion   string `json:"goVersion"`
+	Platform    string `json:"platform"`
+
+	// Instrumentation lists the sanitizers the binary was built with, among
+	// "race", "msan" and "asan". Such builds are much slower and should
+	// never reach production, see InstrumentationWarning.
+	Instrumentation []string `json:"instrumentation,omitempty"`
+
+	raw *debug.BuildInfo
+}
+
+// InstrumentationWarning returns a loud marker for instrumented builds, e.g.
+// "RACE-INSTRUMENTED BUILD", or an empty string.
+func (b Brief) InstrumentationWarning() string {
+	if len(b.Instrumentation) == 0 {
+		return ""
+	}
+
+	return strings.ToUpper(strings.Join(b.Instrumentation, "+")) + "-INSTRUMENTED BUILD"
+}
+
+// Raw returns a copy of the build info the Brief was made from, or nil, so
+// that templates can render any setting or dependency as {{.Raw}}, e.g.
+//
+//	{{range .Raw.Settings}}{{if eq .Key "CGO_ENABLED"}}cgo: {{.Value}}{{end}}{{end}}
+func (b Brief) Raw() *debug.BuildInfo {
+	if b.raw == nil {
+		return nil
+	}
+
+	info := *b.raw
+	info.Main = copyModule(&b.raw.Main)
+	info.Deps = make([]*debug.Module, len(b.raw.Deps))
+	for i, dep := range b.raw.Deps {
+		m := copyModule(dep)
+		info.Deps[i] = &m
+	}
+	info.Settings = append([]debug.BuildSetting(nil), b.raw.Settings...)
+
+	return &info
+}
+
+func copyModule(m *debug.Module) debug.Module {
+	c := *m
+	if m.Replace != nil {
+		r := *m.Replace
+		c.Replace = &r
+	}
+	return c
 }
 
 // Detail provides the field to render a detail version information.
 type Detail struct {
+	SchemaVersion int `json:"schemaVersion"` // see OutputSchemaVersion
+
 	Brief
 	ModVersion
 	VcsInfo
-	TagRemarks string
+	BuildFlags
+	TagRemarks string    `json:"tagRemarks,omitempty"`
+	Distance   *Distance `json:"distance,omitempty"` // see EstimateDistance
+
+	// The deployment the running binary belongs to, e.g. "deploy-1234" in
+	// "staging", read from the environment, see DeploymentIDEnv.
+	DeploymentID string `json:"deploymentId,omitempty"`
+	Environment  string `json:"environment,omitempty"`
+
+	// LibraryVersion is the version of this package which produced the
+	// Detail, see LibraryVersion.
+	LibraryVersion string `json:"libraryVersion,omitempty"`
+
+	Components map[string]string `json:"components,omitempty"`
+	Schemas    []Schema          `json:"schemas,omitempty"`
+	Plugins    []Plugin          `json:"plugins,omitempty"`
 }
 
 // GetAppVersion get Go Application Version from Go binary via debug.BuildInfo.
 //
 // A Go Module Version string layout is one of follow formats:
-//   * dirty vcs work directory: (devel)
-//   * release version: vX.Y.Z
-//   * pre-release version: v1.2.3-RC1
-//   * pseudo version:
-//      - untagged branch: v0.0.0-YYYYmmddHHMMSS-aabbccddeeff
-//      - base on release version: vX.Y.(Z+1)-0.YYYYmmddHHMMSS-aabbccddeeff
-//      - base on pre-release version: vX.Y.Z-RC1.0.YYYYmmddHHMMSS-aabbccddeeff
+//   - dirty vcs work directory: (devel)
+//   - release version: vX.Y.Z
+//   - pre-release version: v1.2.3-RC1
+//   - pseudo version of an untagged branch: v0.0.0-YYYYmmddHHMMSS-aabbccddeeff
+//   - pseudo version based on a release version: vX.Y.(Z+1)-0.YYYYmmddHHMMSS-aabbccddeeff
+//   - pseudo version based on a pre-release version: vX.Y.Z-RC1.0.YYYYmmddHHMMSS-aabbccddeeff
+//   - nightly version: vX.(Y+1).0-dev.YYYYmmdd+aabbccd, see NightlyScheme
 //
-// See also: https://go.dev/ref/mod#glossary
+// For a nightly version, Tag is the version under development.
 //
+// Build metadata such as "+dirty" is ignored. It returns nil for a version
+// which isn't valid semver, so untrusted input is never silently taken for
+// a release.
+//
+// See also: https://go.dev/ref/mod#glossary
 func GetAppVersion(version string) (verInfo *ModVersion) {
 	verInfo = &ModVersion{}
 
 	if version == "" {
-		info, ok := debug.ReadBuildInfo()
+		info, ok := readBuildInfo()
 		if !ok {
 			return nil
 		}
 		version = info.Main.Version
 	}
 
-	parts := strings.Split(version, "-")
-	tag := parts[0]
-	n := len(parts)
-	if n < 3 { // this is not a pseudo version
-		if tag == "(devel)" {
-			verInfo.Type = Devel
-		} else if strings.Contains(tag, "-") {
-			verInfo.Type = PreRelease
-		} else {
-			verInfo.Type = Release
-		}
+	if next, t, commit, ok := NightlyNaming.Parse(version); ok {
+		verInfo.Type = Nightly
+		verInfo.Tag = next
+		verInfo.Time = t
+		verInfo.CommitID = commit
 		return
 	}
 
-	verInfo.CommitID = parts[n-1]
-	timeStr := parts[n-2]
-	actualLen := len(timeStr)
-	expectLen := len("YYYYmmddHHMMSS")
-	if actualLen < expectLen {
-		return nil
+	if version == "(devel)" {
+		verInfo.Type = Devel
+		return
 	}
 
-	t, err := time.Parse("20060102150405", timeStr[actualLen-expectLen:actualLen])
+	v, err := Parse(version)
 	if err != nil {
 		return nil
 	}
 
+	typ, tag, t, commit := pseudoBase(v)
+	verInfo.Type = typ
+	if typ == PreRelease || typ == Release {
+		return
+	}
+	verInfo.Tag = tag
 	verInfo.Time = t
+	verInfo.CommitID = commit
 
-	if actualLen == expectLen {
-		verInfo.Type = PseudoBaseNoTag
-		return
+	return
+}
+
+// splitPseudo splits the pre-release of a pseudo version into the
+// pre-release of its base, the commit time and the commit: "" for
+// "yyyymmddhhmmss-abcdefabcdef", "0" for "0.yyyymmddhhmmss-abcdefabcdef",
+// and "rc.1" for "rc.1.0.yyyymmddhhmmss-abcdefabcdef". ok is false if
+// prerelease isn't the one of a pseudo version.
+func splitPseudo(prerelease string) (base string, t time.Time, commit string, ok bool) {
+	i := strings.LastIndexByte(prerelease, '-')
+	if i < 0 {
+		return "", t, "", false
+	}
+	rest, commit := prerelease[:i], prerelease[i+1:]
+	if len(commit) < 7 || len(commit) > 40 || strings.Trim(commit, "0123456789abcdef") != "" {
+		return "", t, "", false
 	}
 
-	if actualLen == expectLen+2 {
-		parts := strings.Split(tag, ".")
-		patch, _ := strconv.Atoi(parts[2])
-		if patch > 0 {
-			patch = patch - 1
-		}
-		verInfo.Tag = parts[0] + "." + parts[1] + "." + strconv.Itoa(patch)
-		verInfo.Type = PseudoBaseRelease
-		return
+	const timeLen = len("yyyymmddhhmmss")
+	if len(rest) < timeLen || !isDigits(rest[len(rest)-timeLen:]) {
+		return "", t, "", false
+	}
+	t, err := time.Parse("20060102150405", rest[len(rest)-timeLen:])
+	if err != nil {
+		return "", t, "", false
 	}
 
-	tagLen := len(version) - len(".0.yyyymmddhhmmss-aabbccddeeff")
-	verInfo.Tag = version[0:tagLen]
-	verInfo.Type = PseudoBasePreRelease
+	switch prefix := rest[:len(rest)-timeLen]; {
+	case prefix == "":
+		return "", t, commit, true
+	case prefix == "0.":
+		return "0", t, commit, true
+	case strings.HasSuffix(prefix, ".0.") && len(prefix) > len(".0."):
+		return prefix[:len(prefix)-len(".0.")], t, commit, true
+	}
 
-	return
+	return "", t, "", false
 }
 
 // GetVcsInfo extract VCS information from debug.BuildSetting.
 // if settings is nil, GetVcsInfo will call debug.ReadBuildInfo() by itself.
-//
 func GetVcsInfo(settings []debug.BuildSetting) *VcsInfo {
 	if settings == nil {
-		info, ok := debug.ReadBuildInfo()
+		info, ok := readBuildInfo()
 		if !ok {
 			return nil
 		}
@@ -199,6 +331,110 @@ func GetVcsInfo(settings []debug.BuildSetting) *VcsInfo {
 	}
 }
 
+// ErrNoBuildInfo is returned when the binary carries no build information.
+var ErrNoBuildInfo = errors.New("can't get build info")
+
+// GetDetail combines information from GetAppVersion() and GetVcsInfo() of the
+// running binary, it's the data PrintVersion renders.
+func GetDetail() (*Detail, error) {
+	info, ok := readBuildInfo()
+	if !ok {
+		return nil, ErrNoBuildInfo
+	}
+
+	d := newDetail(info)
+	setDeployment(d)
+	d.LibraryVersion = LibraryVersion()
+
+	return d, nil
+}
+
+// newDetail builds a Detail from info. If the version can't be parsed, the
+// returned Detail has the type ErrorVersion.
+func newDetail(info *debug.BuildInfo) *Detail {
+	appName, description, modulePath := resolveNames(info)
+
+	d := &Detail{
+		SchemaVersion: OutputSchemaVersion,
+		Brief: Brief{
+			AppName:     appName,
+			Description: description,
+			ModulePath:  modulePath,
+			AppVersion:  info.Main.Version,
+			GoVersion:   info.GoVersion,
+			Platform:    Platform,
+			raw:         info,
+		},
+		Components: Components(),
+		Schemas:    Schemas(),
+		Plugins:    Plugins(),
+	}
+
+	settings := info.Settings
+	if settings == nil {
+		// a nil slice makes GetVcsInfo read the running binary
+		settings = []debug.BuildSetting{}
+	}
+
+	vcsInfo := GetVcsInfo(settings)
+	verInfo := GetAppVersion(info.Main.Version)
+	if verInfo == nil {
+		verInfo = &ModVersion{Type: ErrorVersion}
+	}
+
+	switch verInfo.Type {
+	case Release, PreRelease:
+		// info.Settings can't contains any valid VCS information.
+	case ErrorVersion:
+		d.TagRemarks = "unknown branch"
+	case Devel:
+		if vcsInfo.IsDirty {
+			d.TagRemarks = "dirty working copy"
+		} else {
+			d.TagRemarks = "clean working copy"
+		}
+	case PseudoBaseNoTag, PseudoBaseRelease, PseudoBasePreRelease:
+		if verInfo.Type == PseudoBaseNoTag {
+			d.TagRemarks = "untagged branch"
+		} else {
+			d.TagRemarks = "branch base on tag " + verInfo.Tag
+		}
+		vcsInfo.Revision = commitRevision(verInfo.CommitID)
+		vcsInfo.LastCommit = verInfo.Time
+	case Nightly:
+		d.TagRemarks = "nightly build of " + verInfo.Tag
+		if verInfo.CommitID != "" {
+			vcsInfo.Revision = commitRevision(verInfo.CommitID)
+		}
+		if vcsInfo.LastCommit.IsZero() {
+			vcsInfo.LastCommit = verInfo.Time
+		}
+	}
+
+	d.ModVersion = *verInfo
+	d.VcsInfo = *vcsInfo
+	d.TimeUnix, d.TimeUTC = clockFormats(d.ModVersion.Time)
+	d.LastCommitUnix, d.LastCommitUTC = clockFormats(d.LastCommit)
+	d.BuildFlags = *GetBuildFlags(settings)
+	d.Instrumentation = instrumentation(settings)
+
+	return d
+}
+
+// clockFormats returns t as Unix seconds and as RFC 3339 in UTC, or zero
+// values if t is zero.
+func clockFormats(t time.Time) (int64, string) {
+	if t.IsZero() {
+		return 0, ""
+	}
+	return t.Unix(), t.UTC().Format(time.RFC3339)
+}
+
+// IsRelease reports whether d describes a release or pre-release version.
+func (d *Detail) IsRelease() bool {
+	return d.Type == Release || d.Type == PreRelease
+}
+
 // PrintVersion combines information from GetAppVersion() and GetVcsInfo(), it
 // provides version information in a human-readable manner.
 // User-supplied writer can extend the scope of PrintVersion, typically with os.Stderr.
@@ -208,109 +444,336 @@ func GetVcsInfo(settings []debug.BuildSetting) *VcsInfo {
 // for brief and detail respectively.
 //
 // The default brief template is:
-//    {{.AppName}} version {{.AppVersion}}, built with {{.GoVersion}}
 //
-// Tnd default detail template is:
-//    WARNING! This is not a release version, it's built from a {{.TagRemarks}}.
+//	{{.AppName}} version {{.AppVersion}}, built with {{.GoVersion}}{{with .InstrumentationWarning}} [{{.}}]{{end}}
 //
-//    VCS information:
-//    VCS:         {{.VCS}}
-//    Module path: {{.ModulePath}}
-//    Commit time: {{.LastCommit.Local.Format "2006-01-02 15:04:05 MST"}}
-//    Revision id: {{.Revision}}
+// Tnd default detail template is:
 //
-//    Please visit {{.ModulePath}} to get updates.
+//	VCS information:
+//	VCS:         {{.VCS}}
+//	Module path: {{.ModulePath}}
+//	Commit time: {{.LastCommit.Local.Format "2006-01-02 15:04:05 MST"}}
+//	Revision id: {{.Revision}}
+//	{{with .Distance}}Distance:    {{.}}
+//	{{end}}{{if or .DeploymentID .Environment}}Deployment:  {{.DeploymentID}}{{with .Environment}} ({{.}}){{end}}
+//	{{end}}{{if .PGOProfile}}PGO profile: {{.PGOProfile}}
+//	{{end}}{{if .CrossCompileHints}}Cross build: {{range $i, $h := .CrossCompileHints}}{{if $i}}, {{end}}{{$h}}{{end}}
+//	{{end}}{{if .Components}}
+//	Components:
+//	{{range $name, $version := .Components}}  {{$name}}: {{$version}}
+//	{{end}}{{end}}{{if .Schemas}}
+//	Data formats:
+//	{{range .Schemas}}  {{.Name}}: {{.Version}}
+//	{{end}}{{end}}{{if or .Experiments .GODEBUG}}
+//	Runtime settings:
+//	{{if .Experiments}}  GOEXPERIMENT: {{range $i, $e := .Experiments}}{{if $i}},{{end}}{{$e}}{{end}}
+//	{{end}}{{if .GODEBUG}}  GODEBUG: {{range $i, $s := .GODEBUG}}{{if $i}},{{end}}{{$s.Name}}={{$s.Value}}{{end}}
+//	{{end}}{{end}}{{if .Plugins}}
+//	Plugins:
+//	{{range .Plugins}}  {{.Name}}: {{.Version}}{{if .Warning}} (WARNING: {{.Warning}}){{end}}
+//	{{end}}{{end}}
+//	Please visit {{.ModulePath}} to get updates.
 //
 // PrintVersion always evaluates brief, and only evaluates detail if the tag is
-// not a release and pre-release tag.
+// not a release and pre-release tag. The default detail template is preceded
+// by the warning:
 //
+//	WARNING! This is not a release version, it's built from a {{.TagRemarks}}.
+//
+// A caller-supplied detail template is printed as is.
+//
+// The output is the same as the "text" Formatter, see TextFormatter. Use
+// PrintVersionWith to configure the warning.
 func PrintVersion(w io.Writer, brief, detail string) {
-	info, ok := debug.ReadBuildInfo()
-	if !ok {
-		fmt.Fprintln(w, "Can't get build info.")
-		return
+	err := PrintVersionWith(Options{Writer: w, Brief: brief, Detail: detail})
+	if err != nil && !errors.Is(err, ErrNoBuildInfo) {
+		panic(err.Error())
 	}
+}
 
-	appName := filepath.Base(info.Path)
+// Options configures PrintVersionWith.
+type Options struct {
+	Writer io.Writer // output, nil means os.Stdout
+	Brief  string    // brief template, empty means DefaultBrief
+	Detail string    // detail template, empty means DefaultDetail
+	Width  int       // wrapping width of the detail block, see TextFormatter
+
+	Warning       WarningMode // how to print the warning of non-release builds, see WarningMode
+	WarningWriter io.Writer   // where to print the warning, nil means DetailWriter
+	DetailWriter  io.Writer   // where to print the warning and detail, nil means Writer
+
+	// Notices prints the third-party notices set by SetNotices after the
+	// detail block, see DefaultNotices.
+	Notices bool
+
+	// Counter, if set, counts the commits of pseudo versions since their
+	// base tag for the detail block, see Detail.EstimateDistance. Failures
+	// leave the count out.
+	Counter CommitCounter
+
+	// Exit codes of PrintVersionAndExit. ExitCode applies to all builds,
+	// unless DevelExitCode or DirtyExitCode is not zero: the former applies
+	// to non-release builds, the latter to builds from a dirty working copy.
+	ExitCode      int
+	DevelExitCode int
+	DirtyExitCode int
+}
 
-	if brief == "" {
-		brief = "{{.AppName}} version {{.AppVersion}}, built with {{.GoVersion}}\n"
+// PrintVersionWith is PrintVersion with options. Unlike PrintVersion it
+// returns template errors instead of panicking; if there is no build info it
+// prints "Can't get build info." and returns ErrNoBuildInfo.
+func PrintVersionWith(opts Options) error {
+	w := opts.Writer
+	if w == nil {
+		w = os.Stdout
 	}
 
-	tmpl, err := template.New("brief").Parse(brief)
+	d, err := GetDetail()
 	if err != nil {
-		panic(fmt.Sprintf("brief template error: %v", err))
+		fmt.Fprintln(w, "Can't get build info.")
+		return err
 	}
 
-	briefInfo := Brief{
-		AppName:    appName,
-		ModulePath: info.Path,
-		AppVersion: info.Main.Version,
-		GoVersion:  info.GoVersion,
+	if opts.Counter != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+		_ = d.EstimateDistance(ctx, opts.Counter)
+		cancel()
 	}
 
-	err = tmpl.Execute(w, briefInfo)
+	f := TextFormatter{
+		Brief:         opts.Brief,
+		Detail:        opts.Detail,
+		Width:         opts.Width,
+		Warning:       opts.Warning,
+		WarningWriter: opts.WarningWriter,
+		DetailWriter:  opts.DetailWriter,
+	}
 
-	if err != nil {
-		panic(fmt.Sprintf("brief template error: %v", err))
+	if err := f.Render(*d, w); err != nil || !opts.Notices {
+		return err
 	}
 
-	vcsInfo := GetVcsInfo(info.Settings)
-	verInfo := GetAppVersion(info.Main.Version)
-	if verInfo == nil || vcsInfo == nil {
-		return
+	if opts.DetailWriter != nil {
+		w = opts.DetailWriter
 	}
 
-	tagRemarks := ""
+	return renderNotices(*d, w)
+}
 
-	switch verInfo.Type {
-	case Release, PreRelease:
-		// info.Settings can't contains any valid VCS information. just return
-		return
-	case ErrorVersion:
-		tagRemarks = "unknown branch"
-	case Devel:
-		if vcsInfo.IsDirty {
-			tagRemarks = "dirty working copy"
-		} else {
-			tagRemarks = "clean working copy"
-		}
-	case PseudoBaseNoTag, PseudoBaseRelease, PseudoBasePreRelease:
-		if verInfo.Type == PseudoBaseNoTag {
-			tagRemarks = "untagged branch"
-		} else {
-			tagRemarks = "branch base on tag " + verInfo.Tag
-		}
-		vcsInfo.Revision = verInfo.CommitID
-		vcsInfo.LastCommit = verInfo.Time
+// PrintVersionAndExit prints the version with PrintVersionWith and exits,
+// which is all a --version flag usually needs:
+//
+//	if *showVersion {
+//	    version.PrintVersionAndExit(version.Options{})
+//	}
+//
+// The exit code is chosen by opts, see Options. If the version can't be
+// printed, the error goes to os.Stderr and the exit code is 2.
+func PrintVersionAndExit(opts Options) {
+	if err := PrintVersionWith(opts); err != nil && !errors.Is(err, ErrNoBuildInfo) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	if detail == "" {
-		detail = `WARNING! This is not a release version, it's built from a {{.TagRemarks}}.
+	os.Exit(opts.exitCode())
+}
 
-VCS information:
+func (opts Options) exitCode() int {
+	// without build info, the binary is treated as a non-release build
+	d, err := GetDetail()
+	switch {
+	case err == nil && d.IsRelease():
+	case err == nil && d.IsDirty && opts.DirtyExitCode != 0:
+		return opts.DirtyExitCode
+	case opts.DevelExitCode != 0:
+		return opts.DevelExitCode
+	}
+
+	return opts.ExitCode
+}
+
+// DefaultBrief is the default brief template, see PrintVersion.
+const DefaultBrief = "{{.AppName}} version {{.AppVersion}}, built with {{.GoVersion}}" +
+	"{{with .InstrumentationWarning}} [{{.}}]{{end}}\n"
+
+// DefaultDetail is the default detail template, see PrintVersion.
+const DefaultDetail = `VCS information:
 VCS:         {{.VCS}}
 Module path: {{.ModulePath}}
 Commit time: {{.LastCommit.Local.Format "2006-01-02 15:04:05 MST"}}
 Revision id: {{.Revision}}
-
+{{with .Distance}}Distance:    {{.}}
+{{end}}{{if or .DeploymentID .Environment}}Deployment:  {{.DeploymentID}}{{with .Environment}} ({{.}}){{end}}
+{{end}}{{if .PGOProfile}}PGO profile: {{.PGOProfile}}
+{{end}}{{if .CrossCompileHints}}Cross build: {{range $i, $h := .CrossCompileHints}}{{if $i}}, {{end}}{{$h}}{{end}}
+{{end}}{{if .Components}}
+Components:
+{{range $name, $version := .Components}}  {{$name}}: {{$version}}
+{{end}}{{end}}{{if .Schemas}}
+Data formats:
+{{range .Schemas}}  {{.Name}}: {{.Version}}
+{{end}}{{end}}{{if or .Experiments .GODEBUG}}
+Runtime settings:
+{{if .Experiments}}  GOEXPERIMENT: {{range $i, $e := .Experiments}}{{if $i}},{{end}}{{$e}}{{end}}
+{{end}}{{if .GODEBUG}}  GODEBUG: {{range $i, $s := .GODEBUG}}{{if $i}},{{end}}{{$s.Name}}={{$s.Value}}{{end}}
+{{end}}{{end}}{{if .Plugins}}
+Plugins:
+{{range .Plugins}}  {{.Name}}: {{.Version}}{{if .Warning}} (WARNING: {{.Warning}}){{end}}
+{{end}}{{end}}
 Please visit {{.ModulePath}} to get updates.
 `
+
+// DefaultWarning is the warning printed before the detail template of
+// non-release builds, see WarningMode.
+const DefaultWarning = "This is not a release version, it's built from a {{.TagRemarks}}."
+
+// WarningMode controls how the warning about non-release builds is printed.
+type WarningMode int
+
+const (
+	WarningDefault WarningMode = iota // WarningShow with DefaultDetail, no warning with custom templates
+	WarningShow                       // "WARNING! ..." followed by a blank line
+	WarningNote                       // "Note: ..." followed by a blank line
+	WarningHide                       // no warning at all
+)
+
+// TextFormatter renders the human-readable output of PrintVersion with the
+// brief and detail templates. Empty templates mean DefaultBrief and
+// DefaultDetail.
+//
+// For non-release builds the warning DefaultWarning is printed before the
+// detail block as configured by Warning, by default only before
+// DefaultDetail; for builds older than the age set
+// by SetStaleAfter it is followed by their StaleWarning, which releases get
+// too.
+//
+// DetailWriter, if not nil, receives the warning and the detail block
+// instead of the output writer, so that scripts capturing the brief line
+// from stdout don't capture them too. WarningWriter, if not nil, receives
+// the warning alone.
+//
+// Long lines of the warning and detail block, such as module paths and
+// URLs, are wrapped to Width columns. Zero means the COLUMNS environment
+// variable, else the width of the terminal if the output is one; a negative
+// Width disables wrapping.
+type TextFormatter struct {
+	Brief  string
+	Detail string
+	Width  int
+
+	Warning       WarningMode
+	WarningWriter io.Writer
+	DetailWriter  io.Writer
+}
+
+// Render implements Formatter.
+func (f TextFormatter) Render(d Detail, w io.Writer) error {
+	brief := f.Brief
+	if brief == "" {
+		brief = DefaultBrief
 	}
 
-	tmpl, err = template.New("detail").Parse(detail)
+	tmpl, err := parseTemplate("brief", brief, d.Brief)
 	if err != nil {
-		panic(fmt.Sprintf("detail template error: %v", err))
+		return fmt.Errorf("brief template error: %v", err)
+	}
+
+	if err = executeTemplate(tmpl, d.Brief, w); err != nil {
+		return fmt.Errorf("brief template error: %v", err)
 	}
 
-	err = tmpl.Execute(w, Detail{
-		Brief:      briefInfo,
-		ModVersion: *verInfo,
-		VcsInfo:    *vcsInfo,
-		TagRemarks: tagRemarks,
-	})
+	if f.DetailWriter != nil {
+		w = f.DetailWriter
+	}
+
+	if d.IsRelease() {
+		// only the staleness warning applies to releases
+		return f.renderWarning(d, w)
+	}
 
+	if err = f.renderWarning(d, w); err != nil {
+		return err
+	}
+
+	detail := f.Detail
+	if detail == "" {
+		detail = DefaultDetail
+	}
+
+	tmpl, err = parseTemplate("detail", detail, d)
 	if err != nil {
-		panic(fmt.Sprintf("detail template error: %v", err))
+		return fmt.Errorf("detail template error: %v", err)
 	}
+
+	if err = executeWrapped(tmpl, d, w, outputWidth(f.Width, w)); err != nil {
+		return fmt.Errorf("detail template error: %v", err)
+	}
+
+	return nil
+}
+
+// Validate checks the brief and detail templates of f, e.g. when they are
+// loaded from a configuration file: they must parse, and the fields they
+// reference must exist. Errors point at the line and column of the
+// offending reference.
+func (f TextFormatter) Validate() error {
+	if f.Brief != "" {
+		if _, err := parseTemplate("brief", f.Brief, Brief{}); err != nil {
+			return fmt.Errorf("brief template error: %v", err)
+		}
+	}
+	if f.Detail != "" {
+		if _, err := parseTemplate("detail", f.Detail, Detail{}); err != nil {
+			return fmt.Errorf("detail template error: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (f TextFormatter) renderWarning(d Detail, w io.Writer) error {
+	var prefix string
+	switch f.Warning {
+	case WarningDefault, WarningShow:
+		prefix = "WARNING! "
+	case WarningNote:
+		prefix = "Note: "
+	default:
+		return nil
+	}
+
+	text := ""
+	if !d.IsRelease() && (f.Detail == "" || f.Warning != WarningDefault) {
+		text = DefaultWarning
+	}
+	if stale := d.StaleWarning(); stale != "" {
+		if text != "" {
+			text += " "
+		}
+		text += stale
+	}
+	if text == "" {
+		return nil
+	}
+
+	// the blank line separates the warning from the detail block, it's
+	// not needed when the warning goes elsewhere or there is no detail
+	suffix := "\n\n"
+	if f.WarningWriter != nil {
+		w = f.WarningWriter
+		suffix = "\n"
+	} else if d.IsRelease() {
+		suffix = "\n"
+	}
+
+	tmpl, err := parseTemplate("warning", prefix+text+suffix, d)
+	if err != nil {
+		return fmt.Errorf("warning template error: %v", err)
+	}
+
+	if err = executeWrapped(tmpl, d, w, outputWidth(f.Width, w)); err != nil {
+		return fmt.Errorf("warning template error: %v", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,67 @@
+package version
+
+import (
+	"strings"
+	"testing"
+)
+
+func addCorpus(f *testing.F) {
+	for _, g := range SemverCorpus() {
+		for _, v := range g {
+			f.Add(v)
+		}
+	}
+	for _, v := range []string{"(devel)", "v1", "v1-0.20260101000000-abc", "garbage", "v1.2.3-dev.20260101+abcdef0"} {
+		f.Add(v)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	addCorpus(f)
+
+	f.Fuzz(func(t *testing.T, s string) {
+		v, err := Parse(s)
+		if err != nil {
+			return
+		}
+
+		// valid versions are canonical, as leading zeros are rejected
+		if got := v.String(); got != s {
+			t.Fatalf("Parse(%q).String() = %q", s, got)
+		}
+		if c := Compare(s, s); c != 0 {
+			t.Fatalf("Compare(%q, %q) = %d", s, s, c)
+		}
+	})
+}
+
+func FuzzGetAppVersion(f *testing.F) {
+	addCorpus(f)
+
+	f.Fuzz(func(t *testing.T, s string) {
+		if s == "" {
+			// reads the build info of the test binary
+			return
+		}
+
+		m := GetAppVersion(s)
+		if m == nil {
+			if _, err := Parse(s); err == nil {
+				t.Fatalf("GetAppVersion(%q) = nil for a valid version", s)
+			}
+			return
+		}
+
+		switch m.Type {
+		case PseudoBaseRelease, PseudoBasePreRelease:
+			if _, err := Parse(m.Tag); err != nil {
+				t.Fatalf("GetAppVersion(%q).Tag = %q: %v", s, m.Tag, err)
+			}
+			fallthrough
+		case PseudoBaseNoTag:
+			if m.CommitID == "" || strings.Trim(m.CommitID, "0123456789abcdef") != "" {
+				t.Fatalf("GetAppVersion(%q).CommitID = %q, want a hex commit", s, m.CommitID)
+			}
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// VersionSet aggregates the versions of the members of a cluster, as
+// exchanged between them by gossip or a shared store, so that a cluster can
+// detect mixed versions and hold back features until all members are
+// upgraded. Versions are ordered like Compare, so a version which is not
+// valid semver, such as (devel), is lower than any valid one.
+//
+// A VersionSet is safe for concurrent use. It encodes to JSON as an object
+// mapping member names to versions.
+type VersionSet struct {
+	mu      sync.RWMutex
+	members map[string]string
+}
+
+// NewVersionSet returns an empty VersionSet.
+func NewVersionSet() *VersionSet {
+	return &VersionSet{members: map[string]string{}}
+}
+
+// Set records the version of member.
+func (s *VersionSet) Set(member, version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.members == nil {
+		s.members = map[string]string{}
+	}
+	s.members[member] = version
+}
+
+// SetSelf records the version of the running binary for member.
+func (s *VersionSet) SetSelf(member string) error {
+	info, ok := readBuildInfo()
+	if !ok {
+		return ErrNoBuildInfo
+	}
+
+	s.Set(member, info.Main.Version)
+	return nil
+}
+
+// Remove forgets member, e.g. when it leaves the cluster.
+func (s *VersionSet) Remove(member string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.members, member)
+}
+
+// Merge records all versions of other, which typically comes from a peer.
+func (s *VersionSet) Merge(other map[string]string) {
+	for member, version := range other {
+		s.Set(member, version)
+	}
+}
+
+// Members returns a copy of all members and their versions.
+func (s *VersionSet) Members() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m := make(map[string]string, len(s.members))
+	for member, version := range s.members {
+		m[member] = version
+	}
+	return m
+}
+
+// Versions returns the distinct versions in the set, highest first.
+func (s *VersionSet) Versions() []string {
+	s.mu.RLock()
+	seen := map[string]bool{}
+	var versions []string
+	for _, v := range s.members {
+		if !seen[v] {
+			seen[v] = true
+			versions = append(versions, v)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(versions, func(i, j int) bool {
+		if c := Compare(versions[i], versions[j]); c != 0 {
+			return c > 0
+		}
+		return versions[i] > versions[j]
+	})
+
+	return versions
+}
+
+// Min returns the lowest version, or "" if the set is empty.
+func (s *VersionSet) Min() string {
+	versions := s.Versions()
+	if len(versions) == 0 {
+		return ""
+	}
+	return versions[len(versions)-1]
+}
+
+// Max returns the highest version, or "" if the set is empty.
+func (s *VersionSet) Max() string {
+	versions := s.Versions()
+	if len(versions) == 0 {
+		return ""
+	}
+	return versions[0]
+}
+
+// Mixed reports whether the members run more than one version.
+func (s *VersionSet) Mixed() bool {
+	return len(s.Versions()) > 1
+}
+
+// AllAtLeast reports whether every member runs version or a later one. It's
+// false for an empty set.
+func (s *VersionSet) AllAtLeast(version string) bool {
+	min := s.Min()
+	return min != "" && Compare(min, version) >= 0
+}
+
+// Render writes a summary of the set, one line per version with the members
+// running it, highest version first.
+func (s *VersionSet) Render(w io.Writer) error {
+	members := s.Members()
+	versions := s.Versions()
+
+	byVersion := map[string][]string{}
+	for member, v := range members {
+		byVersion[v] = append(byVersion[v], member)
+	}
+
+	switch len(versions) {
+	case 0:
+		_, err := fmt.Fprintln(w, "no members")
+		return err
+	case 1:
+		fmt.Fprintf(w, "%d members, all running %s\n", len(members), versions[0])
+	default:
+		fmt.Fprintf(w, "%d members, mixed versions (%s .. %s):\n",
+			len(members), versions[len(versions)-1], versions[0])
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, v := range versions {
+		names := byVersion[v]
+		sort.Strings(names)
+		fmt.Fprintf(tw, "  %s\t%s\n", v, strings.Join(names, ", "))
+	}
+
+	return tw.Flush()
+}
+
+// SkewNotification returns a NotifyVersionSkew notification if the members
+// run mixed versions, or nil otherwise.
+func (s *VersionSet) SkewNotification(app string) *Notification {
+	if !s.Mixed() {
+		return nil
+	}
+
+	var b strings.Builder
+	s.Render(&b)
+
+	return &Notification{
+		Kind:    NotifyVersionSkew,
+		Time:    time.Now(),
+		App:     app,
+		Title:   "version skew in " + app + " cluster",
+		Message: strings.TrimSpace(b.String()),
+		Fields: map[string]string{
+			"min": s.Min(),
+			"max": s.Max(),
+		},
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s *VersionSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Members())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, merging the decoded members
+// into s.
+func (s *VersionSet) UnmarshalJSON(data []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	s.Merge(m)
+	return nil
+}
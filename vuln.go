@@ -0,0 +1,96 @@
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// VulnChecker finds the known vulnerabilities of the modules and the Go
+// toolchain a binary is built with, see ReadinessOptions. OSVChecker
+// implements it.
+type VulnChecker interface {
+	// Vulnerabilities returns the IDs of the known vulnerabilities
+	// affecting info, by module path, "stdlib" for the Go toolchain.
+	Vulnerabilities(ctx context.Context, info *debug.BuildInfo) (map[string][]string, error)
+}
+
+// OSVChecker is a VulnChecker using the OSV database at https://osv.dev,
+// which includes the Go vulnerability database. It reports every
+// vulnerability of the module versions, whether or not the binary calls
+// the affected code; run govulncheck for a precise analysis.
+type OSVChecker struct {
+	URL    string       // query batch API, default https://api.osv.dev/v1/querybatch
+	Client *http.Client // default RemoteOptions.Client
+}
+
+type osvQuery struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Version string `json:"version"`
+}
+
+// Vulnerabilities implements VulnChecker.
+func (c *OSVChecker) Vulnerabilities(ctx context.Context, info *debug.BuildInfo) (map[string][]string, error) {
+	var queries []osvQuery
+	add := func(name, version string) {
+		var q osvQuery
+		q.Package.Name, q.Package.Ecosystem = name, "Go"
+		q.Version = strings.TrimPrefix(version, "v")
+		queries = append(queries, q)
+	}
+
+	for _, m := range builtModules(info) {
+		// modules without a version, such as local replacements, can't
+		// be looked up
+		if m.Path != "" && m.Version != "" && m.Version != "(devel)" {
+			add(m.Path, m.Version)
+		}
+	}
+	if goVersion := strings.TrimPrefix(info.GoVersion, "go"); goVersion != "" {
+		// e.g. go1.22.1 X:nocoverageredesign
+		add("stdlib", strings.Fields(goVersion)[0])
+	}
+
+	body, err := json.Marshal(map[string][]osvQuery{"queries": queries})
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.URL
+	if u == "" {
+		u = "https://api.osv.dev/v1/querybatch"
+	}
+	data, err := post(ctx, c.Client, u, "application/json", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var r struct {
+		Results []struct {
+			Vulns []struct {
+				ID string `json:"id"`
+			} `json:"vulns"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+
+	vulns := make(map[string][]string)
+	for i, res := range r.Results {
+		if i >= len(queries) {
+			break
+		}
+		for _, v := range res.Vulns {
+			name := queries[i].Package.Name
+			vulns[name] = append(vulns[name], v.ID)
+		}
+	}
+
+	return vulns, nil
+}
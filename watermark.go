@@ -0,0 +1,111 @@
+package version
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WatermarkInfo is the information carried by a watermark line.
+type WatermarkInfo struct {
+	App     string
+	Version string
+	Commit  string // abbreviated revision id, may be empty
+	Time    time.Time
+}
+
+// ErrNoWatermark is returned by ParseWatermark if there is no watermark.
+var ErrNoWatermark = errors.New("no watermark found")
+
+// watermarkPattern matches the fields of WatermarkInfo.String. The app name
+// may contain spaces, the version can't, so the version is the last word
+// before the commit or the time.
+var watermarkPattern = regexp.MustCompile(
+	`Generated by (.+?) (\S+)(?: \(commit ([0-9A-Za-z]+)\))? at ([0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9:.]+(?:Z|[+-][0-9]{2}:[0-9]{2}))\.`)
+
+// Watermark returns a standardized line for stamping generated files, such as
+//
+//	// Generated by mygen v1.2.3 (commit 0123456789ab) at 2024-06-05T10:00:00Z.
+//
+// commentPrefix is put in front of the line, e.g. "//" or "#", and may be
+// empty. The time is the current time, or SOURCE_DATE_EPOCH if it's set, to
+// allow reproducible output. ParseWatermark reads the line back.
+func Watermark(commentPrefix string) string {
+	d, err := GetDetail()
+	if err != nil {
+		d = &Detail{Brief: Brief{AppName: executableName(), AppVersion: "unknown"}}
+	}
+
+	w := WatermarkInfo{
+		App:     d.AppName,
+		Version: d.AppVersion,
+		Time:    watermarkTime(),
+	}
+
+	if d.Revision != "" && d.Revision != "unknown" {
+		w.Commit = d.Revision
+		if len(w.Commit) > 12 {
+			w.Commit = w.Commit[:12]
+		}
+	}
+
+	line := w.String()
+	if commentPrefix != "" {
+		line = commentPrefix + " " + line
+	}
+
+	return line
+}
+
+// watermarkTime honors SOURCE_DATE_EPOCH, see
+// https://reproducible-builds.org/specs/source-date-epoch/
+func watermarkTime() time.Time {
+	if s := os.Getenv("SOURCE_DATE_EPOCH"); s != "" {
+		if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(sec, 0).UTC()
+		}
+	}
+
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// String returns the watermark line without comment prefix.
+func (w *WatermarkInfo) String() string {
+	var b strings.Builder
+
+	b.WriteString("Generated by " + w.App + " " + w.Version)
+	if w.Commit != "" {
+		b.WriteString(" (commit " + w.Commit + ")")
+	}
+	b.WriteString(" at " + w.Time.Format(time.RFC3339) + ".")
+
+	return b.String()
+}
+
+// ParseWatermark parses a line produced by Watermark, with any comment
+// prefix.
+func ParseWatermark(line string) (*WatermarkInfo, error) {
+	m := watermarkPattern.FindStringSubmatch(line)
+	if m == nil {
+		return nil, ErrNoWatermark
+	}
+
+	return watermarkFromMatch(m)
+}
+
+func watermarkFromMatch(m []string) (*WatermarkInfo, error) {
+	t, err := time.Parse(time.RFC3339, m[4])
+	if err != nil {
+		return nil, err
+	}
+
+	return &WatermarkInfo{
+		App:     m[1],
+		Version: m[2],
+		Commit:  m[3],
+		Time:    t,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package version
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// minWrapWidth is the narrowest width worth wrapping to, below it the
+// output would only get harder to read.
+const minWrapWidth = 20
+
+// outputWidth returns the width the detail block written to w should be
+// wrapped to, or 0 for no wrapping. A positive width is used as is and a
+// negative one disables wrapping. Zero means the COLUMNS environment
+// variable, else the width of the terminal w is attached to, if any.
+func outputWidth(width int, w io.Writer) int {
+	switch {
+	case width < 0:
+		return 0
+	case width > 0:
+		return width
+	}
+
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+
+	if f, ok := w.(*os.File); ok {
+		return fileWidth(f)
+	}
+
+	return 0
+}
+
+// executeWrapped executes tmpl with data and writes the result to w,
+// wrapped to width if it is positive.
+func executeWrapped(tmpl *template.Template, data interface{}, w io.Writer, width int) error {
+	if width <= 0 {
+		return executeTemplate(tmpl, data, w)
+	}
+
+	var buf bytes.Buffer
+	if err := executeTemplate(tmpl, data, &buf); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, wrapText(buf.String(), width))
+	return err
+}
+
+// wrapText soft-breaks lines of s longer than width, measured in runes.
+// Lines are broken after a space, or after a '/', '?', '&' or ',' so that
+// long module paths, URLs and lists break at a natural place, and are only
+// broken in the middle of a word if there is no such place. Continuation
+// lines are indented to the value of "Label: value" lines, e.g. the module
+// path after "Module path: ".
+func wrapText(s string, width int) string {
+	if width < minWrapWidth {
+		return s
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+	var b strings.Builder
+	for _, line := range lines {
+		text := []rune(strings.TrimSuffix(line, "\n"))
+		if len(text) <= width {
+			b.WriteString(line)
+			continue
+		}
+
+		indent := strings.Repeat(" ", valueColumn(text, width))
+		first := true
+		for len(text) > 0 {
+			avail := width
+			if !first {
+				avail -= len(indent)
+				b.WriteString(indent)
+			}
+
+			n := breakPoint(text, avail)
+			b.WriteString(strings.TrimRight(string(text[:n]), " "))
+			text = trimLeftSpaces(text[n:])
+			if len(text) > 0 {
+				b.WriteByte('\n')
+			}
+			first = false
+		}
+
+		if strings.HasSuffix(line, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+
+	return b.String()
+}
+
+// valueColumn returns the column at which the value of a "Label: value" line
+// starts, or the indentation of other lines. It is at most width/2, so that
+// continuation lines keep enough room.
+func valueColumn(line []rune, width int) int {
+	col := len(line) - len(trimLeftSpaces(line))
+	for i := 0; i+1 < len(line); i++ {
+		if line[i] == ':' && line[i+1] == ' ' {
+			rest := line[i+1:]
+			col = i + 1 + len(rest) - len(trimLeftSpaces(rest))
+			break
+		}
+	}
+
+	if col > width/2 {
+		col = width / 2
+	}
+
+	return col
+}
+
+// breakPoint returns the length of the longest prefix of text not longer
+// than width that ends at a natural break, or width if there is none.
+func breakPoint(text []rune, width int) int {
+	if len(text) <= width {
+		return len(text)
+	}
+
+	// skip the leading indentation, breaking there would be pointless
+	start := len(text) - len(trimLeftSpaces(text))
+	for i := width; i > start; i-- {
+		switch text[i-1] {
+		case ' ', '/', '?', '&', ',':
+			return i
+		}
+	}
+
+	return width
+}
+
+func trimLeftSpaces(text []rune) []rune {
+	for len(text) > 0 && text[0] == ' ' {
+		text = text[1:]
+	}
+	return text
+}